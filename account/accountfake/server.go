@@ -0,0 +1,259 @@
+// Package that provides an in-memory fake of Form3 accountapi which can be used in tests instead of the docker stack.
+//
+// The NewServer function starts the fake on a random local port, Url returns its address which can be passed to
+// account.ClientConfig, NewClient returns account.Client calling it.
+//
+// It implements Create, Fetch, List, Delete and Health endpoints with the same status codes as accountapi
+// (404 on missing accounts, 409 on duplicates and wrong versions, 400 on invalid bodies).
+//
+// Create honours `Idempotency-Key` header: a repeated key with the same body replays the stored response
+// instead of creating the account again, a repeated key with a different body is rejected with HTTP-422.
+//
+// DropResponses makes the fake handle requests but answer them with an error, as if the response was lost,
+// which allows to test retries of non-idempotent calls.
+//
+package accountfake
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/retry"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const accountsPath = "/v1/organisation/accounts"
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+type Server struct {
+	*httptest.Server
+	mutex            sync.Mutex
+	accounts         map[string]*account.Account
+	order            []string
+	idempotency      map[string]*storedResponse
+	droppedResponses []int
+	requests         []*http.Request
+}
+
+type storedResponse struct {
+	fingerprint [32]byte
+	statusCode  int
+	body        []byte
+}
+
+type errorResponse struct {
+	ErrorMessage string `json:"error_message"`
+}
+
+// Starts new fake server, it has to be closed with Close
+func NewServer() *Server {
+	server := &Server{
+		accounts:    make(map[string]*account.Account),
+		idempotency: make(map[string]*storedResponse),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.handle))
+	return server
+}
+
+// Returns account.Client calling the fake with 3 retries and 1ms delays, so tests don't wait for retries
+func (s *Server) NewClient() *account.Client {
+	client, _ := account.NewClient(account.ClientConfig{
+		Timeout:       time.Second,
+		Url:           s.Url(),
+		RetriesConfig: &retry.RetriesConfig{MaxRetries: 3, Delay: time.Millisecond, Factor: 2},
+	})
+	return client
+}
+
+// Returns the base URL of the fake
+func (s *Server) Url() *url.URL {
+	parsed, _ := url.Parse(s.Server.URL)
+	return parsed
+}
+
+// Makes the next count requests to be handled as usual but answered with statusCode and an empty body
+func (s *Server) DropResponses(statusCode int, count int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := 0; i < count; i++ {
+		s.droppedResponses = append(s.droppedResponses, statusCode)
+	}
+}
+
+// Returns copies of all the stored accounts in the order of creation
+func (s *Server) Accounts() []account.Account {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	accounts := make([]account.Account, 0, len(s.order))
+	for _, id := range s.order {
+		accounts = append(accounts, *s.accounts[id])
+	}
+	return accounts
+}
+
+// Returns all the requests received by the fake (with already consumed bodies)
+func (s *Server) Requests() []*http.Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]*http.Request{}, s.requests...)
+}
+
+func (s *Server) handle(response http.ResponseWriter, request *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = append(s.requests, request)
+
+	statusCode, body := s.route(request)
+
+	if len(s.droppedResponses) > 0 {
+		statusCode, body = s.droppedResponses[0], nil
+		s.droppedResponses = s.droppedResponses[1:]
+	}
+	response.Header().Set("Content-Type", "application/vnd.api+json")
+	response.Header().Set("X-Request-Id", strconv.Itoa(len(s.requests)))
+	response.WriteHeader(statusCode)
+	response.Write(body)
+}
+
+func (s *Server) route(request *http.Request) (int, []byte) {
+	path := strings.TrimSuffix(request.URL.Path, "/")
+	switch {
+	case path == "/v1/health" && request.Method == "GET":
+		return respond(http.StatusOK, map[string]string{"status": "up"})
+	case path == accountsPath && request.Method == "POST":
+		return s.create(request)
+	case path == accountsPath && request.Method == "GET":
+		return s.list(request)
+	case strings.HasPrefix(path, accountsPath+"/") && request.Method == "GET":
+		return s.fetch(strings.TrimPrefix(path, accountsPath+"/"))
+	case strings.HasPrefix(path, accountsPath+"/") && request.Method == "DELETE":
+		return s.delete(strings.TrimPrefix(path, accountsPath+"/"), request)
+	default:
+		return respondError(http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) create(request *http.Request) (int, []byte) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return respondError(http.StatusBadRequest, err.Error())
+	}
+
+	key := request.Header.Get("Idempotency-Key")
+	fingerprint := sha256.Sum256(body)
+	if stored, ok := s.idempotency[key]; ok && len(key) > 0 {
+		if stored.fingerprint != fingerprint {
+			return respondError(http.StatusUnprocessableEntity, "idempotency key was used with a different request")
+		}
+		return stored.statusCode, stored.body
+	}
+
+	statusCode, responseBody := s.createAccount(body)
+	if len(key) > 0 && statusCode < 500 {
+		s.idempotency[key] = &storedResponse{fingerprint: fingerprint, statusCode: statusCode, body: responseBody}
+	}
+	return statusCode, responseBody
+}
+
+func (s *Server) createAccount(body []byte) (int, []byte) {
+	var createRequest account.CreateAccountRequest
+	if err := json.Unmarshal(body, &createRequest); err != nil || createRequest.Account == nil {
+		return respondError(http.StatusBadRequest, "invalid request body")
+	}
+	created := *createRequest.Account
+	if created.Attributes == nil || !countryPattern.MatchString(created.Attributes.Country) {
+		return respondError(http.StatusBadRequest, "validation failure list:\nvalidation failure list:\ncountry in body should match '^[A-Z]{2}$'")
+	}
+	if _, ok := s.accounts[created.Id]; ok {
+		return respondError(http.StatusConflict, "Account cannot be created as it violates a duplicate constraint")
+	}
+
+	attributes := *created.Attributes
+	now := time.Now().UTC()
+	created.Type = "accounts"
+	created.Attributes = &attributes
+	created.Version = 0
+	created.CreatedOn = now
+	created.ModifiedOn = now
+	s.accounts[created.Id] = &created
+	s.order = append(s.order, created.Id)
+	return respond(http.StatusCreated, account.CreateAccountResponse{Account: &created})
+}
+
+func (s *Server) fetch(id string) (int, []byte) {
+	found, ok := s.accounts[id]
+	if !ok {
+		return respondError(http.StatusNotFound, fmt.Sprintf("record %s does not exist", id))
+	}
+	return respond(http.StatusOK, account.FetchAccountResponse{Account: found})
+}
+
+func (s *Server) list(request *http.Request) (int, []byte) {
+	query := request.URL.Query()
+	pageNumber, err := parseInt(query.Get("page[number]"), 0)
+	if err != nil || pageNumber < 0 {
+		return respondError(http.StatusBadRequest, "invalid page number")
+	}
+	pageSize, err := parseInt(query.Get("page[size]"), 100)
+	if err != nil || pageSize < 0 {
+		return respondError(http.StatusBadRequest, "invalid page size")
+	}
+	if pageSize == 0 {
+		pageSize = 100
+	}
+
+	accounts := []*account.Account{}
+	for i := pageNumber * pageSize; i < len(s.order) && i < (pageNumber+1)*pageSize; i++ {
+		accounts = append(accounts, s.accounts[s.order[i]])
+	}
+	return respond(http.StatusOK, account.ListAccountResponse{Accounts: accounts})
+}
+
+func (s *Server) delete(id string, request *http.Request) (int, []byte) {
+	found, ok := s.accounts[id]
+	if !ok {
+		return http.StatusNotFound, nil
+	}
+	version, err := parseInt(request.URL.Query().Get("version"), -1)
+	if err != nil || version < 0 {
+		return respondError(http.StatusBadRequest, "invalid version number")
+	}
+	if version != found.Version {
+		return respondError(http.StatusConflict, "invalid version")
+	}
+
+	delete(s.accounts, id)
+	for i, orderedId := range s.order {
+		if orderedId == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return http.StatusNoContent, nil
+}
+
+func parseInt(value string, fallback int) (int, error) {
+	if len(value) == 0 {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func respond(statusCode int, body interface{}) (int, []byte) {
+	js, _ := json.Marshal(body)
+	return statusCode, js
+}
+
+func respondError(statusCode int, message string) (int, []byte) {
+	return respond(statusCode, errorResponse{ErrorMessage: message})
+}
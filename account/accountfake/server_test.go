@@ -0,0 +1,123 @@
+package accountfake
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/http"
+	"accountapi-client/retry"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCreateWithLostResponse(t *testing.T) {
+	t.Logf("Given fake server losing the first response")
+	server := NewServer()
+	defer server.Close()
+	server.DropResponses(503, 1)
+
+	t.Logf("And given account client")
+	client := server.NewClient()
+
+	t.Logf("When creating account with an idempotency key")
+	request := createAccountRequest()
+	response, err := client.Create(context.Background(), request, account.WithIdempotencyKey("c4d5e6"))
+
+	t.Logf("Should retry with the same Idempotency-Key and create the account once")
+	assert.NoError(t, err)
+	assert.Equal(t, request.Account.Id, response.Account.Id)
+	assert.Equal(t, 1, len(server.Accounts()))
+	requests := server.Requests()
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, "c4d5e6", requests[0].Header.Get("Idempotency-Key"))
+	assert.Equal(t, "c4d5e6", requests[1].Header.Get("Idempotency-Key"))
+}
+
+func TestCreateWithLostResponseAndGeneratedKey(t *testing.T) {
+	t.Logf("Given fake server losing the first response")
+	server := NewServer()
+	defer server.Close()
+	server.DropResponses(503, 1)
+
+	t.Logf("And given account client")
+	client := server.NewClient()
+
+	t.Logf("When creating account without an idempotency key")
+	response, err := client.Create(context.Background(), createAccountRequest())
+
+	t.Logf("Should send a generated key but not retry it")
+	var httpError *http.ClientHttpError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Equal(t, 503, httpError.StatusCode)
+	assert.Nil(t, response)
+	requests := server.Requests()
+	assert.Equal(t, 1, len(requests))
+	assert.NotEmpty(t, requests[0].Header.Get("Idempotency-Key"))
+}
+
+func TestCreateWithReusedIdempotencyKey(t *testing.T) {
+	t.Logf("Given fake server")
+	server := NewServer()
+	defer server.Close()
+
+	t.Logf("And given account client")
+	client := server.NewClient()
+
+	t.Logf("When creating the same account twice with the same key")
+	ctx := http.WithIdempotencyKey(context.Background(), "8c1e8b9a")
+	request := createAccountRequest()
+	first, firstErr := client.Create(ctx, request)
+	second, secondErr := client.Create(ctx, request)
+
+	t.Logf("Should replay the first response")
+	assert.NoError(t, firstErr)
+	assert.NoError(t, secondErr)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, len(server.Accounts()))
+
+	t.Logf("When creating another account with the same key")
+	response, err := client.Create(ctx, createAccountRequest())
+
+	t.Logf("Should return 422 HTTP error")
+	var httpError *http.ClientHttpError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Equal(t, 422, httpError.StatusCode)
+	assert.Nil(t, response)
+}
+
+func TestCreateWithoutIdempotencyKeys(t *testing.T) {
+	t.Logf("Given fake server losing the first response")
+	server := NewServer()
+	defer server.Close()
+	server.DropResponses(503, 1)
+
+	t.Logf("And given account client without idempotency keys")
+	client := server.NewClient()
+	client.Client, _ = http.NewClient(http.ClientConfig{
+		Timeout:                time.Second,
+		Retries:                &retry.RetriesConfig{MaxRetries: 3, Delay: time.Millisecond, Factor: 2},
+		DisableIdempotencyKeys: true,
+	})
+
+	t.Logf("When creating account")
+	response, err := client.Create(context.Background(), createAccountRequest())
+
+	t.Logf("Should not retry it")
+	var httpError *http.ClientHttpError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Equal(t, 503, httpError.StatusCode)
+	assert.Nil(t, response)
+	assert.Equal(t, 1, len(server.Requests()))
+	assert.Equal(t, 1, len(server.Accounts()))
+}
+
+func createAccountRequest() *account.CreateAccountRequest {
+	return &account.CreateAccountRequest{Account: &account.Account{
+		Type:           "accounts",
+		Id:             uuid.New().String(),
+		OrganisationId: uuid.New().String(),
+		Attributes:     &account.Attributes{Country: "GB", Name: []string{"Jan"}},
+	}}
+}
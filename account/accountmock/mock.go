@@ -0,0 +1,269 @@
+// Provides Mock, a hand-maintained implementation of account.AccountService for tests of its consumers.
+//
+// Responses are scripted with expectations, matched in the order they were registered:
+//
+//	mock := accountmock.New()
+//	mock.On(accountmock.Fetch, accountmock.Id("ad27b0e5-8e82-4a4d-a0c1-2ae8ab7a8b4e")).
+//		Return(nil, &http.ClientHttpError{StatusCode: 503}).
+//		Return(&account.FetchAccountResponse{Account: &account.Account{...}}, nil)
+//	mock.On(accountmock.Delete).Return(nil, nil).Times(1)
+//
+//	service := NewService(mock) // code under test
+//	...
+//	mock.AssertExpectations(t)
+//
+// Every call is recorded (see Calls), calls not matching any expectation fail with UnexpectedCallError.
+package accountmock
+
+import (
+	"accountapi-client/account"
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Names of mocked operations
+const (
+	Create = "Create"
+	Fetch  = "Fetch"
+	List   = "List"
+	Delete = "Delete"
+	Health = "Health"
+)
+
+// Returned by the Mock when a call doesn't match any expectation
+type UnexpectedCallError struct {
+	Method  string
+	Request interface{}
+}
+
+func (e *UnexpectedCallError) Error() string {
+	return fmt.Sprintf("accountmock: unexpected call %s(%+v)", e.Method, e.Request)
+}
+
+// Recorded call, Request is nil for Health
+type Call struct {
+	Method  string
+	Ctx     context.Context
+	Request interface{}
+	Options []account.CallOption
+}
+
+// Decides whether the request of a call matches the expectation
+type Matcher func(request interface{}) bool
+
+// Matches any request
+func Any() Matcher {
+	return func(request interface{}) bool {
+		return true
+	}
+}
+
+// Matches requests deeply equal to the expected one
+func Equal(expected interface{}) Matcher {
+	return func(request interface{}) bool {
+		return reflect.DeepEqual(expected, request)
+	}
+}
+
+// Matches requests concerning the account id, that is Account.Id of CreateAccountRequest and Id of other requests
+func Id(id string) Matcher {
+	return func(request interface{}) bool {
+		switch request := request.(type) {
+		case *account.CreateAccountRequest:
+			return request.Account != nil && request.Account.Id == id
+		case *account.FetchAccountRequest:
+			return request.Id == id
+		case *account.DeleteAccountRequest:
+			return request.Id == id
+		default:
+			return false
+		}
+	}
+}
+
+// Matches requests of the type for which the function returns true, e.g.
+//
+//	accountmock.Func(func(request *account.ListAccountsRequest) bool { return request.PageNumber > 0 })
+func Func(matches interface{}) Matcher {
+	function := reflect.ValueOf(matches)
+	return func(request interface{}) bool {
+		argument := reflect.ValueOf(request)
+		if !argument.IsValid() || argument.Type() != function.Type().In(0) {
+			return false
+		}
+		return function.Call([]reflect.Value{argument})[0].Bool()
+	}
+}
+
+// Scripted responses of calls matching the method and matchers
+type Expectation struct {
+	method   string
+	matchers []Matcher
+	results  []result
+	times    int
+	calls    int
+	mutex    *sync.Mutex
+}
+
+type result struct {
+	response interface{}
+	err      error
+}
+
+// Adds a response returned by the next call, the last response is repeated once all of them are used.
+// The response has to be of the type returned by the method (e.g. *account.FetchAccountResponse) or nil.
+func (e *Expectation) Return(response interface{}, err error) *Expectation {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.results = append(e.results, result{response: response, err: err})
+	return e
+}
+
+// Limits the number of calls matching the expectation, AssertExpectations checks all of them were made
+func (e *Expectation) Times(times int) *Expectation {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.times = times
+	return e
+}
+
+// Mock of account.AccountService, see package docs
+type Mock struct {
+	mutex        sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+}
+
+var _ account.AccountService = (*Mock)(nil)
+
+func New() *Mock {
+	return &Mock{}
+}
+
+// Registers an expectation of calls to the method with requests matching all the matchers
+func (m *Mock) On(method string, matchers ...Matcher) *Expectation {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	expectation := &Expectation{method: method, matchers: matchers, mutex: &m.mutex}
+	m.expectations = append(m.expectations, expectation)
+	return expectation
+}
+
+// Returns all the recorded calls, or only calls to the methods if they are provided
+func (m *Mock) Calls(methods ...string) []Call {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var calls []Call
+	for _, call := range m.calls {
+		if len(methods) == 0 || contains(methods, call.Method) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Fails the test if any expectation limited with Times wasn't called the expected number of times
+func (m *Mock) AssertExpectations(t TestingT) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	ok := true
+	for _, expectation := range m.expectations {
+		if expectation.times > 0 && expectation.calls != expectation.times {
+			t.Errorf("accountmock: expected %d calls to %s, got %d", expectation.times, expectation.method, expectation.calls)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Subset of testing.T used by AssertExpectations
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+func (m *Mock) Create(ctx context.Context, request *account.CreateAccountRequest, opts ...account.CallOption) (*account.CreateAccountResponse, error) {
+	response, err := m.call(Create, ctx, request, opts)
+	if response == nil {
+		return nil, err
+	}
+	return response.(*account.CreateAccountResponse), err
+}
+
+func (m *Mock) Fetch(ctx context.Context, request *account.FetchAccountRequest, opts ...account.CallOption) (*account.FetchAccountResponse, error) {
+	response, err := m.call(Fetch, ctx, request, opts)
+	if response == nil {
+		return nil, err
+	}
+	return response.(*account.FetchAccountResponse), err
+}
+
+func (m *Mock) List(ctx context.Context, request *account.ListAccountsRequest, opts ...account.CallOption) (*account.ListAccountResponse, error) {
+	response, err := m.call(List, ctx, request, opts)
+	if response == nil {
+		return nil, err
+	}
+	return response.(*account.ListAccountResponse), err
+}
+
+func (m *Mock) Delete(ctx context.Context, request *account.DeleteAccountRequest, opts ...account.CallOption) error {
+	_, err := m.call(Delete, ctx, request, opts)
+	return err
+}
+
+func (m *Mock) Health(ctx context.Context, opts ...account.CallOption) (*account.Health, error) {
+	response, err := m.call(Health, ctx, nil, opts)
+	if response == nil {
+		return nil, err
+	}
+	return response.(*account.Health), err
+}
+
+// Records the call and returns the next result of the first matching expectation which isn't exhausted
+func (m *Mock) call(method string, ctx context.Context, request interface{}, opts []account.CallOption) (interface{}, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Ctx: ctx, Request: request, Options: opts})
+
+	for _, expectation := range m.expectations {
+		if expectation.method != method || !expectation.matches(request) {
+			continue
+		}
+		if expectation.times > 0 && expectation.calls >= expectation.times {
+			continue
+		}
+		expectation.calls++
+		if len(expectation.results) == 0 {
+			return nil, nil
+		}
+		index := expectation.calls - 1
+		if index >= len(expectation.results) {
+			index = len(expectation.results) - 1
+		}
+		result := expectation.results[index]
+		if value := reflect.ValueOf(result.response); value.Kind() == reflect.Ptr && value.IsNil() {
+			return nil, result.err
+		}
+		return result.response, result.err
+	}
+	return nil, &UnexpectedCallError{Method: method, Request: request}
+}
+
+func (e *Expectation) matches(request interface{}) bool {
+	for _, matcher := range e.matchers {
+		if !matcher(request) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
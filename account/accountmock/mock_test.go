@@ -0,0 +1,91 @@
+package accountmock
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/http"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMockWithScriptedResponses(t *testing.T) {
+	t.Logf("Given mock failing the first fetch of an account and returning it afterwards")
+	id := "ad27b0e5-8e82-4a4d-a0c1-2ae8ab7a8b4e"
+	fetched := &account.FetchAccountResponse{Account: &account.Account{Id: id}}
+	mock := New()
+	mock.On(Fetch, Id(id)).
+		Return(nil, &http.ClientHttpError{StatusCode: 503}).
+		Return(fetched, nil)
+
+	t.Logf("When fetching it three times")
+	var service account.AccountService = mock
+	first, firstErr := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+	second, secondErr := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+	third, thirdErr := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+
+	t.Logf("Should return scripted responses and repeat the last one")
+	var httpError *http.ClientHttpError
+	assert.True(t, errors.As(firstErr, &httpError))
+	assert.Nil(t, first)
+	assert.NoError(t, secondErr)
+	assert.Equal(t, fetched, second)
+	assert.NoError(t, thirdErr)
+	assert.Equal(t, fetched, third)
+
+	t.Logf("And should record the calls")
+	calls := mock.Calls(Fetch)
+	assert.Equal(t, 3, len(calls))
+	assert.Equal(t, &account.FetchAccountRequest{Id: id}, calls[0].Request)
+}
+
+func TestMockWithMatchers(t *testing.T) {
+	t.Logf("Given mock expecting deletes of a version and lists of the first page")
+	mock := New()
+	mock.On(Delete, Equal(&account.DeleteAccountRequest{Id: "1", Version: 0})).Return(nil, nil).Times(1)
+	mock.On(List, Func(func(request *account.ListAccountsRequest) bool { return request.PageNumber == 0 })).
+		Return(&account.ListAccountResponse{}, nil)
+
+	t.Logf("When calling it with matching and not matching requests")
+	deleteErr := mock.Delete(context.Background(), &account.DeleteAccountRequest{Id: "1", Version: 0}, account.NoRetry())
+	secondDeleteErr := mock.Delete(context.Background(), &account.DeleteAccountRequest{Id: "1", Version: 0})
+	list, listErr := mock.List(context.Background(), &account.ListAccountsRequest{PageNumber: 0, PageSize: 10})
+	_, secondListErr := mock.List(context.Background(), &account.ListAccountsRequest{PageNumber: 1, PageSize: 10})
+
+	t.Logf("Should respond to matching calls only")
+	assert.NoError(t, deleteErr)
+	assert.NoError(t, listErr)
+	assert.NotNil(t, list)
+	var unexpectedCallError *UnexpectedCallError
+	assert.True(t, errors.As(secondDeleteErr, &unexpectedCallError))
+	assert.Equal(t, Delete, unexpectedCallError.Method)
+	assert.True(t, errors.As(secondListErr, &unexpectedCallError))
+
+	t.Logf("And should record options and satisfy expectations")
+	assert.Equal(t, 1, len(mock.Calls(Delete)[0].Options))
+	assert.Equal(t, 4, len(mock.Calls()))
+	assert.True(t, mock.AssertExpectations(t))
+}
+
+func TestMockAssertExpectations(t *testing.T) {
+	t.Logf("Given mock expecting two creates")
+	mock := New()
+	mock.On(Create, Any()).Times(2)
+
+	t.Logf("When creating an account once")
+	mock.Create(context.Background(), &account.CreateAccountRequest{})
+
+	t.Logf("Should report unmet expectation")
+	recorder := &errorRecorder{}
+	assert.False(t, mock.AssertExpectations(recorder))
+	assert.Equal(t, []string{"accountmock: expected 2 calls to Create, got 1"}, recorder.errors)
+}
+
+type errorRecorder struct {
+	errors []string
+}
+
+func (e *errorRecorder) Errorf(format string, args ...interface{}) {
+	e.errors = append(e.errors, fmt.Sprintf(format, args...))
+}
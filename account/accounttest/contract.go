@@ -0,0 +1,214 @@
+// Provides a conformance suite for implementations of account.AccountService.
+//
+// RunContract encodes behaviours of accountapi (round trips, pagination, versioning and missing accounts),
+// so the same suite can prove that the real API, accountfake.Server and decorators of account.Client behave identically:
+//
+//	func TestContract(t *testing.T) {
+//		accounttest.RunContract(t, func(t *testing.T) account.AccountService {
+//			server := accountfake.NewServer()
+//			t.Cleanup(server.Close)
+//			client, _ := account.NewClient(account.ClientConfig{Timeout: time.Second, Url: server.Url()})
+//			return client
+//		})
+//	}
+//
+// Accounts created by the suite are deleted once every subtest finishes, so it can be run against shared environments.
+package accounttest
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/http"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// Creates the AccountService under test, it's called once per subtest
+type Factory func(t *testing.T) account.AccountService
+
+// Runs all the behaviours of the contract as subtests of t
+func RunContract(t *testing.T, factory Factory) {
+	t.Run("CreateThenFetch", func(t *testing.T) { testCreateThenFetch(t, factory(t)) })
+	t.Run("CreateDuplicate", func(t *testing.T) { testCreateDuplicate(t, factory(t)) })
+	t.Run("FetchMissing", func(t *testing.T) { testFetchMissing(t, factory(t)) })
+	t.Run("ListPagination", func(t *testing.T) { testListPagination(t, factory(t)) })
+	t.Run("DeleteWithWrongVersion", func(t *testing.T) { testDeleteWithWrongVersion(t, factory(t)) })
+	t.Run("DeleteThenFetch", func(t *testing.T) { testDeleteThenFetch(t, factory(t)) })
+	t.Run("DeleteMissing", func(t *testing.T) { testDeleteMissing(t, factory(t)) })
+	t.Run("Validation", func(t *testing.T) { testValidation(t, factory(t)) })
+}
+
+func testCreateThenFetch(t *testing.T, service account.AccountService) {
+	t.Logf("Given valid create request")
+	request := NewCreateAccountRequest()
+
+	t.Logf("When creating account")
+	created := create(t, service, request)
+
+	t.Logf("Should return it with the first version")
+	assert.Equal(t, request.Account.Id, created.Id)
+	assert.Equal(t, request.Account.OrganisationId, created.OrganisationId)
+	assert.Equal(t, "accounts", created.Type)
+	assert.Equal(t, 0, created.Version)
+	assert.Equal(t, request.Account.Attributes, created.Attributes)
+
+	t.Logf("When fetching it")
+	fetched, err := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Id})
+
+	t.Logf("Should return the same account")
+	require.NoError(t, err)
+	assert.Equal(t, created.Id, fetched.Account.Id)
+	assert.Equal(t, created.OrganisationId, fetched.Account.OrganisationId)
+	assert.Equal(t, created.Version, fetched.Account.Version)
+	assert.Equal(t, created.Attributes, fetched.Account.Attributes)
+}
+
+func testCreateDuplicate(t *testing.T, service account.AccountService) {
+	t.Logf("Given existing account")
+	request := NewCreateAccountRequest()
+	create(t, service, request)
+
+	t.Logf("When creating it again")
+	duplicate := NewCreateAccountRequest()
+	duplicate.Account.Id = request.Account.Id
+	response, err := service.Create(context.Background(), duplicate)
+
+	t.Logf("Should return 409 HTTP error")
+	assertStatusCode(t, 409, err)
+	assert.Nil(t, response)
+}
+
+func testFetchMissing(t *testing.T, service account.AccountService) {
+	t.Logf("When fetching not existing account")
+	response, err := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: newUuid()})
+
+	t.Logf("Should return 404 HTTP error")
+	assertStatusCode(t, 404, err)
+	assert.Nil(t, response)
+}
+
+func testListPagination(t *testing.T, service account.AccountService) {
+	t.Logf("Given 3 new accounts")
+	created := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		created[create(t, service, NewCreateAccountRequest()).Id] = true
+	}
+
+	t.Logf("When listing all the pages of size 2")
+	listed := map[string]bool{}
+	pageSize := 2
+	for pageNumber := 0; ; pageNumber++ {
+		response, err := service.List(context.Background(), &account.ListAccountsRequest{PageNumber: pageNumber, PageSize: pageSize})
+		require.NoError(t, err)
+		require.True(t, len(response.Accounts) <= pageSize, "page %d has %d accounts", pageNumber, len(response.Accounts))
+		for _, listedAccount := range response.Accounts {
+			assert.False(t, listed[listedAccount.Id], "account %s is listed twice", listedAccount.Id)
+			listed[listedAccount.Id] = true
+		}
+		if len(response.Accounts) < pageSize {
+			t.Logf("Should end with a partial or empty page %d", pageNumber)
+			break
+		}
+	}
+
+	t.Logf("And should list all the accounts once")
+	for id := range created {
+		assert.True(t, listed[id], "account %s is not listed", id)
+	}
+}
+
+func testDeleteWithWrongVersion(t *testing.T, service account.AccountService) {
+	t.Logf("Given existing account")
+	created := create(t, service, NewCreateAccountRequest())
+
+	t.Logf("When deleting it with wrong version")
+	err := service.Delete(context.Background(), &account.DeleteAccountRequest{Id: created.Id, Version: created.Version + 1})
+
+	t.Logf("Should return 409 HTTP error and keep the account")
+	assertStatusCode(t, 409, err)
+	_, err = service.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Id})
+	assert.NoError(t, err)
+}
+
+func testDeleteThenFetch(t *testing.T, service account.AccountService) {
+	t.Logf("Given existing account")
+	request := NewCreateAccountRequest()
+	created, err := service.Create(context.Background(), request)
+	require.NoError(t, err)
+
+	t.Logf("When deleting it")
+	err = service.Delete(context.Background(), &account.DeleteAccountRequest{Id: created.Account.Id, Version: created.Account.Version})
+
+	t.Logf("Should not return any errors and should not find it anymore")
+	assert.NoError(t, err)
+	_, err = service.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Account.Id})
+	assertStatusCode(t, 404, err)
+}
+
+func testDeleteMissing(t *testing.T, service account.AccountService) {
+	t.Logf("When deleting not existing account")
+	err := service.Delete(context.Background(), &account.DeleteAccountRequest{Id: newUuid(), Version: 0})
+
+	t.Logf("Should return 404 HTTP error")
+	assertStatusCode(t, 404, err)
+}
+
+func testValidation(t *testing.T, service account.AccountService) {
+	t.Logf("When calling operations with invalid requests")
+	_, createErr := service.Create(context.Background(), &account.CreateAccountRequest{Account: &account.Account{Id: "aa"}})
+	_, fetchErr := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: ""})
+	_, listErr := service.List(context.Background(), &account.ListAccountsRequest{PageNumber: -1, PageSize: 1})
+	deleteErr := service.Delete(context.Background(), &account.DeleteAccountRequest{Id: newUuid(), Version: -1})
+
+	t.Logf("Should return ValidationError")
+	for _, err := range []error{createErr, fetchErr, listErr, deleteErr} {
+		var validationError *account.ValidationError
+		assert.True(t, errors.As(err, &validationError), "expected ValidationError, got %v", err)
+	}
+}
+
+// Returns a valid request creating account with new UUID V1 ids
+func NewCreateAccountRequest() *account.CreateAccountRequest {
+	return &account.CreateAccountRequest{Account: &account.Account{
+		Type:           "accounts",
+		Id:             newUuid(),
+		OrganisationId: newUuid(),
+		Attributes: &account.Attributes{
+			Country:      "GB",
+			BaseCurrency: "GBP",
+			BankId:       "400300",
+			BankIdCode:   "GBDSC",
+			Bic:          "NWBKGB22",
+			Name:         []string{"Jan Kowalski"},
+		},
+	}}
+}
+
+// Creates the account and deletes it once the test finishes
+func create(t *testing.T, service account.AccountService, request *account.CreateAccountRequest) *account.Account {
+	response, err := service.Create(context.Background(), request)
+	require.NoError(t, err)
+	created := response.Account
+	t.Cleanup(func() {
+		service.Delete(context.Background(), &account.DeleteAccountRequest{Id: created.Id, Version: created.Version})
+	})
+	return created
+}
+
+func assertStatusCode(t *testing.T, statusCode int, err error) {
+	var httpError *http.ClientHttpError
+	if assert.True(t, errors.As(err, &httpError), "expected ClientHttpError, got %v", err) {
+		assert.Equal(t, statusCode, httpError.StatusCode)
+	}
+}
+
+func newUuid() string {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		panic(err)
+	}
+	return id.String()
+}
@@ -0,0 +1,31 @@
+package accounttest
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"accountapi-client/http"
+	"accountapi-client/logging"
+	"testing"
+	"time"
+)
+
+func TestContractWithFakeServer(t *testing.T) {
+	RunContract(t, func(t *testing.T) account.AccountService {
+		return newFakeClient(t)
+	})
+}
+
+func TestContractWithDecorators(t *testing.T) {
+	RunContract(t, func(t *testing.T) account.AccountService {
+		return account.Chain(newFakeClient(t),
+			account.LoggingDecorator(logging.Nop()),
+			account.MetricsDecorator(http.NewPrometheusMetrics("")),
+			account.CachingDecorator(time.Minute))
+	})
+}
+
+func newFakeClient(t *testing.T) *account.Client {
+	server := accountfake.NewServer()
+	t.Cleanup(server.Close)
+	return server.NewClient()
+}
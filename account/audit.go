@@ -0,0 +1,137 @@
+package account
+
+import (
+	"accountapi-client/logging"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Describes a mutation made through AccountService, see AuditDecorator
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// Caller provided with WithPrincipal, empty if it's missing
+	Principal string `json:"principal"`
+	Operation string `json:"operation"`
+	AccountId string `json:"account_id"`
+	// Version before the mutation, nil for Create
+	VersionBefore *int `json:"version_before"`
+	// Version after the mutation, nil for Delete and failed calls
+	VersionAfter *int `json:"version_after"`
+	// Hex encoded SHA-256 of the JSON encoded request
+	RequestHash string `json:"request_hash"`
+	// One of `ok`, `validation`, `blocked`, `dry_run`, `4xx`, `5xx` or `error`
+	Outcome string `json:"outcome"`
+	// Error of the call redacted with logging.Redact
+	Error string `json:"error,omitempty"`
+}
+
+// Stores AuditRecords, e.g. audit.FileSink. It's called synchronously after every mutation,
+// so it has to be safe for concurrent use.
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// Adapter that allows to use an ordinary function as an AuditSink
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+func (f AuditSinkFunc) Record(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+// Returned by calls which couldn't be recorded by AuditSink, the outcome of the call itself is in AuditRecord
+type AuditError struct {
+	Record AuditRecord
+	Err    error
+}
+
+func (e *AuditError) Error() string {
+	return fmt.Sprintf("failed to record %s of account %s: %s", e.Record.Operation, e.Record.AccountId, e.Err)
+}
+
+func (e *AuditError) Unwrap() error {
+	return e.Err
+}
+
+type principalKey struct{}
+
+// Returns a copy of the context carrying the principal (e.g. user or service name) recorded by AuditDecorator
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Returns the principal set with WithPrincipal, empty if it's missing
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// Records every Create and Delete (including the failed and refused ones) in the sink, other operations are
+// passed through. If the sink fails, the call returns AuditError along with its response,
+// so the mutation may have been made even though the error is returned.
+func AuditDecorator(sink AuditSink) Decorator {
+	return func(next AccountService) AccountService {
+		return &auditedService{AccountService: next, sink: sink, now: time.Now}
+	}
+}
+
+type auditedService struct {
+	AccountService
+	sink AuditSink
+	now  func() time.Time
+}
+
+func (s *auditedService) Create(ctx context.Context, request *CreateAccountRequest, opts ...CallOption) (*CreateAccountResponse, error) {
+	response, err := s.AccountService.Create(ctx, request, opts...)
+	record := s.newRecord(ctx, CreateOperation, request, err)
+	if request != nil && request.Account != nil {
+		record.AccountId = request.Account.Id
+	}
+	if err == nil && response != nil && response.Account != nil {
+		version := response.Account.Version
+		record.VersionAfter = &version
+	}
+	return response, s.record(ctx, record, err)
+}
+
+func (s *auditedService) Delete(ctx context.Context, request *DeleteAccountRequest, opts ...CallOption) error {
+	err := s.AccountService.Delete(ctx, request, opts...)
+	record := s.newRecord(ctx, DeleteOperation, request, err)
+	if request != nil {
+		version := request.Version
+		record.AccountId = request.Id
+		record.VersionBefore = &version
+	}
+	return s.record(ctx, record, err)
+}
+
+func (s *auditedService) newRecord(ctx context.Context, operation string, request interface{}, err error) AuditRecord {
+	record := AuditRecord{
+		Time:        s.now().UTC(),
+		Principal:   PrincipalFromContext(ctx),
+		Operation:   operation,
+		RequestHash: hashRequest(request),
+		Outcome:     outcome(err),
+	}
+	if err != nil {
+		record.Error = logging.Redact(err.Error())
+	}
+	return record
+}
+
+// Stores the record and returns the error of the call, or AuditError if the record couldn't be stored
+func (s *auditedService) record(ctx context.Context, record AuditRecord, err error) error {
+	if recordErr := s.sink.Record(ctx, record); recordErr != nil {
+		return &AuditError{Record: record, Err: recordErr}
+	}
+	return err
+}
+
+func hashRequest(request interface{}) string {
+	js, _ := json.Marshal(request)
+	hash := sha256.Sum256(js)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,148 @@
+// Package that provides an append-only JSON-lines implementation of account.AuditSink.
+//
+// Every line of the file is an Entry: account.AuditRecord along with the hash of the previous entry and its own hash
+// (SHA-256 of the previous hash and the JSON encoded record). Verify detects entries which were modified, removed
+// or reordered in the middle of the file. The hashes aren't keyed, so it doesn't detect entries removed from the end
+// of the file nor a file rewritten with a recomputed chain. To detect those, store FileSink.Checkpoint outside
+// of the file (e.g. in a database or another system) and verify the file with VerifyCheckpoint.
+//
+//	sink, err := audit.NewFileSink("/var/log/accountapi/audit.jsonl")
+//	service := account.Chain(client, account.AuditDecorator(sink))
+//	err = service.Delete(account.WithPrincipal(ctx, "jan@example.com"), request)
+//	checkpoint := sink.Checkpoint() // stored elsewhere
+//	...
+//	err = audit.VerifyCheckpoint(file, checkpoint)
+package audit
+
+import (
+	"accountapi-client/account"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Line of the audit file
+type Entry struct {
+	account.AuditRecord
+	// Hash of the previous entry, empty for the first one
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// account.AuditSink appending entries to a file, it's safe for concurrent use within a single process
+type FileSink struct {
+	mutex      sync.Mutex
+	file       auditFile
+	checkpoint Checkpoint
+	// Size of the file up to the last entry
+	size int64
+	err  error
+}
+
+type auditFile interface {
+	io.WriteCloser
+	Sync() error
+	Truncate(size int64) error
+}
+
+// Returned by FileSink.Record once a partially written entry couldn't be removed from the file,
+// the sink refuses to write after it so the chain is not continued from a broken entry
+var SinkBrokenError = errors.New("audit file has a partially written entry")
+
+// State of the chain after a number of entries, see VerifyCheckpoint
+type Checkpoint struct {
+	Entries int    `json:"entries"`
+	Hash    string `json:"hash"`
+}
+
+// Opens the file at path (creating it if it's missing) and continues its hash chain.
+//
+// If the existing file is tampered with it returns TamperedError, so the chain is never continued from broken entries.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint, err := verify(file, nil)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileSink{file: file, checkpoint: checkpoint, size: size}, nil
+}
+
+// Appends the record to the file and syncs it to the disk.
+//
+// If the write fails, the file is truncated to the last entry. If that fails as well, it returns SinkBrokenError
+// then and on every following call.
+func (s *FileSink) Record(ctx context.Context, record account.AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+
+	hash, err := hashEntry(s.checkpoint.Hash, record)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(Entry{AuditRecord: record, PrevHash: s.checkpoint.Hash, Hash: hash})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := s.file.Write(line); err != nil {
+		return s.rollback(err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return s.rollback(err)
+	}
+	s.checkpoint = Checkpoint{Entries: s.checkpoint.Entries + 1, Hash: hash}
+	s.size += int64(len(line))
+	return nil
+}
+
+// Removes the entry which failed to be written
+func (s *FileSink) rollback(err error) error {
+	if truncateErr := s.file.Truncate(s.size); truncateErr != nil {
+		s.err = fmt.Errorf("%w: %v", SinkBrokenError, truncateErr)
+		return s.err
+	}
+	return err
+}
+
+// Returns the number of entries in the file and the hash of the last one
+func (s *FileSink) Checkpoint() Checkpoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.checkpoint
+}
+
+// Closes the file
+func (s *FileSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.file.Close()
+}
+
+func hashEntry(prevHash string, record account.AuditRecord) (string, error) {
+	js, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(prevHash))
+	hash.Write(js)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
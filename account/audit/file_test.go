@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountmock"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	t.Logf("Given file sink")
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	assert.NoError(t, err)
+
+	t.Logf("And given service audited with it")
+	mock := accountmock.New()
+	mock.On(accountmock.Create).Return(&account.CreateAccountResponse{Account: &account.Account{Id: "1"}}, nil)
+	mock.On(accountmock.Delete).Return(nil, nil)
+	service := account.Chain(mock, account.AuditDecorator(sink))
+
+	t.Logf("When creating and deleting accounts and reopening the sink in between")
+	ctx := account.WithPrincipal(context.Background(), "jan@example.com")
+	service.Create(ctx, &account.CreateAccountRequest{Account: &account.Account{Id: "1"}})
+	assert.NoError(t, sink.Close())
+	sink, err = NewFileSink(path)
+	assert.NoError(t, err)
+	defer sink.Close()
+	service = account.Chain(mock, account.AuditDecorator(sink))
+	service.Delete(ctx, &account.DeleteAccountRequest{Id: "1"})
+	service.Delete(ctx, &account.DeleteAccountRequest{Id: "2"})
+
+	t.Logf("Should write a single chain of entries")
+	assert.NoError(t, VerifyFile(path))
+	assert.Equal(t, 3, sink.Checkpoint().Entries)
+	lines := readLines(t, path)
+	assert.Equal(t, 3, len(lines))
+	assert.True(t, strings.Contains(lines[0], `"principal":"jan@example.com","operation":"account.create","account_id":"1"`), lines[0])
+	assert.True(t, strings.Contains(lines[0], `"prev_hash":""`), lines[0])
+}
+
+func TestFileSinkWithFailedWrite(t *testing.T) {
+	t.Logf("Given file sink with an entry")
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := NewFileSink(path)
+	assert.NoError(t, err)
+	defer sink.Close()
+	record := account.AuditRecord{Principal: "jan", Operation: account.DeleteOperation, AccountId: "1", Outcome: "ok"}
+	assert.NoError(t, sink.Record(context.Background(), record))
+
+	t.Logf("And given its file failing in the middle of the next write")
+	file := &failingFile{File: sink.file.(*os.File), failWrite: true}
+	sink.file = file
+
+	t.Logf("When recording two more entries")
+	firstErr := sink.Record(context.Background(), record)
+	secondErr := sink.Record(context.Background(), record)
+
+	t.Logf("Should remove the partially written entry and continue the chain")
+	assert.Error(t, firstErr)
+	assert.NoError(t, secondErr)
+	assert.NoError(t, VerifyFile(path))
+	assert.Equal(t, 2, len(readLines(t, path)))
+
+	t.Logf("When the partially written entry can't be removed")
+	file.failWrite, file.failTruncate = true, true
+	firstErr = sink.Record(context.Background(), record)
+	file.failWrite, file.failTruncate = false, false
+	secondErr = sink.Record(context.Background(), record)
+
+	t.Logf("Should refuse further writes")
+	assert.True(t, errors.Is(firstErr, SinkBrokenError))
+	assert.True(t, errors.Is(secondErr, SinkBrokenError))
+}
+
+// Writes half of the data and fails once failWrite is set, fails truncating while failTruncate is set
+type failingFile struct {
+	*os.File
+	failWrite    bool
+	failTruncate bool
+}
+
+func (f *failingFile) Write(data []byte) (int, error) {
+	if !f.failWrite {
+		return f.File.Write(data)
+	}
+	f.failWrite = false
+	written, _ := f.File.Write(data[:len(data)/2])
+	return written, errors.New("no space left on device")
+}
+
+func (f *failingFile) Truncate(size int64) error {
+	if f.failTruncate {
+		return errors.New("input/output error")
+	}
+	return f.File.Truncate(size)
+}
+
+func TestVerify(t *testing.T) {
+	t.Logf("Given audit file with three entries and its checkpoint")
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	lines, checkpoint := writeEntries(t, path, "jan")
+
+	t.Logf("And given a file with the chain recomputed for other entries")
+	recomputed, _ := writeEntries(t, filepath.Join(t.TempDir(), "audit.jsonl"), "eve")
+
+	cases := []struct {
+		name       string
+		lines      []string
+		checkpoint *Checkpoint
+		line       int
+		reason     string
+	}{
+		{"modified entry", []string{lines[0], strings.Replace(lines[1], `"principal":"jan"`, `"principal":"eve"`, 1), lines[2]}, nil, 2, "hash mismatch"},
+		{"removed entry", []string{lines[0], lines[2]}, nil, 2, "broken chain"},
+		{"reordered entries", []string{lines[1], lines[0], lines[2]}, nil, 1, "broken chain"},
+		{"malformed entry", []string{lines[0], "{", lines[2]}, nil, 2, "malformed entry"},
+		{"truncated entries", []string{lines[0], lines[1]}, &checkpoint, 3, "missing entries"},
+		{"recomputed chain", recomputed, &checkpoint, 3, "checkpoint mismatch"},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Logf("When verifying the file with %s", c.name)
+			reader := strings.NewReader(strings.Join(c.lines, "\n") + "\n")
+			var err error
+			if c.checkpoint != nil {
+				err = VerifyCheckpoint(reader, *c.checkpoint)
+			} else {
+				err = Verify(reader)
+			}
+
+			t.Logf("Should point at the broken entry")
+			var tamperedError *TamperedError
+			assert.True(t, errors.As(err, &tamperedError))
+			assert.Equal(t, &TamperedError{Line: c.line, Reason: c.reason}, tamperedError)
+		})
+	}
+
+	t.Logf("When verifying the truncated file without the checkpoint")
+	err := Verify(strings.NewReader(lines[0] + "\n" + lines[1] + "\n"))
+
+	t.Logf("Should not detect it")
+	assert.NoError(t, err)
+
+	t.Logf("When verifying the file with more entries than the checkpoint")
+	sink, _ := NewFileSink(path)
+	sink.Record(context.Background(), account.AuditRecord{Principal: "jan", Operation: account.DeleteOperation, AccountId: "4", Outcome: "ok"})
+	sink.Close()
+	err = VerifyCheckpoint(strings.NewReader(strings.Join(readLines(t, path), "\n")+"\n"), checkpoint)
+
+	t.Logf("Should accept the entries appended after it")
+	assert.NoError(t, err)
+
+	t.Logf("When opening a sink on the tampered file")
+	ioutil.WriteFile(path, []byte(lines[0]+"\n"+lines[2]+"\n"), 0600)
+	_, err = NewFileSink(path)
+
+	t.Logf("Should refuse to continue the chain")
+	var tamperedError *TamperedError
+	assert.True(t, errors.As(err, &tamperedError))
+}
+
+// Writes three entries of the principal and returns the lines of the file along with its checkpoint
+func writeEntries(t *testing.T, path string, principal string) ([]string, Checkpoint) {
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		sink.Record(context.Background(), account.AuditRecord{Principal: principal, Operation: account.DeleteOperation, AccountId: id, Outcome: "ok"})
+	}
+	checkpoint := sink.Checkpoint()
+	sink.Close()
+	return readLines(t, path), checkpoint
+}
+
+func readLines(t *testing.T, path string) []string {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Returned by Verify when an entry doesn't match the chain
+type TamperedError struct {
+	// Number of the line starting from 1
+	Line   int
+	Reason string
+}
+
+func (e *TamperedError) Error() string {
+	return fmt.Sprintf("audit log is tampered at line %d: %s", e.Line, e.Reason)
+}
+
+// Verifies the hash chain of the audit file at path, it returns TamperedError pointing at the first broken entry
+func VerifyFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return Verify(file)
+}
+
+// Verifies the hash chain of entries read from the reader, see VerifyFile
+func Verify(reader io.Reader) error {
+	_, err := verify(reader, nil)
+	return err
+}
+
+// Verifies the hash chain like Verify and that it contains the entry of the checkpoint taken earlier,
+// entries appended after the checkpoint are allowed. It returns TamperedError pointing at the entry of the checkpoint
+// if the chain was truncated ("missing entries") or recomputed ("checkpoint mismatch").
+func VerifyCheckpoint(reader io.Reader, checkpoint Checkpoint) error {
+	_, err := verify(reader, &checkpoint)
+	return err
+}
+
+// Returns the checkpoint of the last entry
+func verify(reader io.Reader, expected *Checkpoint) (Checkpoint, error) {
+	buffered := bufio.NewReader(reader)
+	var checkpoint Checkpoint
+	for number := 1; ; number++ {
+		line, err := buffered.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			if expected != nil && checkpoint.Entries < expected.Entries {
+				return Checkpoint{}, &TamperedError{Line: expected.Entries, Reason: "missing entries"}
+			}
+			return checkpoint, nil
+		}
+		if err != nil && err != io.EOF {
+			return Checkpoint{}, err
+		}
+		if err == io.EOF {
+			return Checkpoint{}, &TamperedError{Line: number, Reason: "truncated entry"}
+		}
+
+		var entry Entry
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&entry); err != nil {
+			return Checkpoint{}, &TamperedError{Line: number, Reason: "malformed entry"}
+		}
+		if entry.PrevHash != checkpoint.Hash {
+			return Checkpoint{}, &TamperedError{Line: number, Reason: "broken chain"}
+		}
+		hash, err := hashEntry(entry.PrevHash, entry.AuditRecord)
+		if err != nil || hash != entry.Hash {
+			return Checkpoint{}, &TamperedError{Line: number, Reason: "hash mismatch"}
+		}
+		checkpoint = Checkpoint{Entries: number, Hash: entry.Hash}
+		if expected != nil && expected.Entries == number && expected.Hash != entry.Hash {
+			return Checkpoint{}, &TamperedError{Line: number, Reason: "checkpoint mismatch"}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package account
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Caches successful Fetch responses by account id for the ttl, other operations are passed through.
+// Entries are dropped by Delete and Create of the same account, so reads of the same service stay consistent,
+// Fetch responses which were in flight while the account was deleted or created are not cached.
+// Expired entries are swept at most once per ttl, when new entries are put.
+//
+// Fetch calls with options (e.g. WithResponseInfo or WithTimeout) are never served from the cache, so the options
+// always take effect, their responses are cached for other calls. Cached responses are copies, modifying them
+// doesn't affect the cache.
+func CachingDecorator(ttl time.Duration) Decorator {
+	return func(next AccountService) AccountService {
+		return &cachingService{
+			AccountService: next,
+			ttl:            ttl,
+			entries:        make(map[string]cacheEntry),
+			fetches:        make(map[string]*pendingFetch),
+			now:            time.Now,
+		}
+	}
+}
+
+type cachingService struct {
+	AccountService
+	ttl     time.Duration
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+	fetches map[string]*pendingFetch
+	sweptAt time.Time
+	now     func() time.Time
+}
+
+// Fetches of an account in flight, generation is bumped whenever the account is invalidated
+type pendingFetch struct {
+	count      int
+	generation uint64
+}
+
+type cacheEntry struct {
+	account   Account
+	expiresAt time.Time
+}
+
+func (s *cachingService) Fetch(ctx context.Context, request *FetchAccountRequest, opts ...CallOption) (*FetchAccountResponse, error) {
+	if request == nil {
+		return s.AccountService.Fetch(ctx, request, opts...)
+	}
+	if len(opts) == 0 {
+		if cached, ok := s.get(request.Id); ok {
+			return &FetchAccountResponse{Account: cached}, nil
+		}
+	}
+	generation := s.startFetch(request.Id)
+	response, err := s.AccountService.Fetch(ctx, request, opts...)
+	var fetched *Account
+	if err == nil && response != nil && response.Account != nil && response.Account.Id == request.Id {
+		fetched = response.Account
+	}
+	s.finishFetch(request.Id, generation, fetched)
+	return response, err
+}
+
+func (s *cachingService) Create(ctx context.Context, request *CreateAccountRequest, opts ...CallOption) (*CreateAccountResponse, error) {
+	if request != nil && request.Account != nil {
+		s.invalidate(request.Account.Id)
+	}
+	return s.AccountService.Create(ctx, request, opts...)
+}
+
+func (s *cachingService) Delete(ctx context.Context, request *DeleteAccountRequest, opts ...CallOption) error {
+	err := s.AccountService.Delete(ctx, request, opts...)
+	if request != nil {
+		s.invalidate(request.Id)
+	}
+	return err
+}
+
+func (s *cachingService) get(id string) (*Account, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if !s.now().Before(entry.expiresAt) {
+		delete(s.entries, id)
+		return nil, false
+	}
+	account := copyAccount(entry.account)
+	return &account, true
+}
+
+func (s *cachingService) startFetch(id string) uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	fetch, ok := s.fetches[id]
+	if !ok {
+		fetch = &pendingFetch{}
+		s.fetches[id] = fetch
+	}
+	fetch.count++
+	return fetch.generation
+}
+
+// Puts the fetched account unless it was invalidated since the fetch started
+func (s *cachingService) finishFetch(id string, generation uint64, account *Account) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	fetch := s.fetches[id]
+	if account != nil && fetch.generation == generation {
+		s.put(account)
+	}
+	fetch.count--
+	if fetch.count == 0 {
+		delete(s.fetches, id)
+	}
+}
+
+// Must be called with the mutex held
+func (s *cachingService) put(account *Account) {
+	now := s.now()
+	if now.Sub(s.sweptAt) >= s.ttl {
+		for id, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, id)
+			}
+		}
+		s.sweptAt = now
+	}
+	s.entries[account.Id] = cacheEntry{account: copyAccount(*account), expiresAt: now.Add(s.ttl)}
+}
+
+func (s *cachingService) invalidate(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, id)
+	if fetch, ok := s.fetches[id]; ok {
+		fetch.generation++
+	}
+}
+
+func copyAccount(account Account) Account {
+	if account.Attributes != nil {
+		attributes := *account.Attributes
+		attributes.Name = append([]string(nil), attributes.Name...)
+		attributes.AlternativeNames = append([]string(nil), attributes.AlternativeNames...)
+		account.Attributes = &attributes
+	}
+	return account
+}
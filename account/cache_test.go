@@ -0,0 +1,34 @@
+package account
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fetchingService struct {
+	AccountService
+}
+
+func (s *fetchingService) Fetch(ctx context.Context, request *FetchAccountRequest, opts ...CallOption) (*FetchAccountResponse, error) {
+	return &FetchAccountResponse{Account: &Account{Id: request.Id}}, nil
+}
+
+func TestCachingDecoratorSweepsExpiredEntries(t *testing.T) {
+	t.Logf("Given caching decorator with a controlled clock")
+	now := time.Now()
+	service := CachingDecorator(time.Minute)(&fetchingService{}).(*cachingService)
+	service.now = func() time.Time { return now }
+
+	t.Logf("When fetching two accounts and another one after they expire")
+	service.Fetch(context.Background(), &FetchAccountRequest{Id: "1"})
+	service.Fetch(context.Background(), &FetchAccountRequest{Id: "2"})
+	now = now.Add(2 * time.Minute)
+	service.Fetch(context.Background(), &FetchAccountRequest{Id: "3"})
+
+	t.Logf("Should keep only the entry which hasn't expired")
+	assert.Equal(t, 1, len(service.entries))
+	assert.Contains(t, service.entries, "3")
+	assert.Empty(t, service.fetches)
+}
@@ -20,39 +20,140 @@ package account
 
 import (
 	"accountapi-client/http"
+	"accountapi-client/logging"
 	"accountapi-client/retry"
 	"context"
 	"fmt"
+	corehttp "net/http"
 	"net/url"
 	"strconv"
 	"time"
 )
 
 type ClientConfig struct {
-	Timeout       time.Duration
-	Logging       bool
-	Url           *url.URL
-	RetriesConfig *retry.RetriesConfig
+	Timeout                time.Duration
+	CallTimeout            time.Duration
+	Transport              corehttp.RoundTripper
+	TransportConfig        *http.TransportConfig
+	TLS                    *http.TLSConfig
+	Logging                bool
+	Logger                 logging.Logger
+	Metrics                http.Metrics
+	Tracer                 http.Tracer
+	Url                    *url.URL
+	Urls                   []*url.URL
+	HealthCheckInterval    time.Duration
+	RetriesConfig          *retry.RetriesConfig
+	RetryPolicy            http.RetryPolicy
+	DisableIdempotencyKeys bool
+	RequestIdHeader        string
+	Auth                   http.Authenticator
+	Signer                 http.Signer
+	Middlewares            []http.Middleware
+	CallMiddlewares        []http.Middleware
+	DryRun                 bool
+	ReadOnly               bool
+	DeletionPolicy         DeletionPolicy
+	CoalesceRequests       bool
 }
 
 type Client struct {
 	Url    *url.URL
 	Client *http.Client
+	// Makes Create and Delete return http.DryRunError instead of sending their requests
+	DryRun bool
+	// Makes Create and Delete return ReadOnlyError
+	ReadOnly bool
+	// Checked by Delete, nil allows deleting any account
+	DeletionPolicy DeletionPolicy
 }
 
 // Creates new instance of Client.
 //
-// If ClientConfig.Timeout is zero or bellow it returns TimeoutZeroError.
+// If ClientConfig.Timeout is zero or bellow it returns TimeoutZeroError. Timeout limits every attempt,
+// CallTimeout limits the whole call including retries.
 //
-// If ClientConfig.RetriesConfig has any errors, those will be also returned to the caller,
-// not providing those values is not possible as retries are required on all of the endpoints.
+// Transport or TransportConfig allow tuning connection pooling and timeouts of the network, see http.NewTransport.
+// TLS configures client certificates (reloaded on file change), root CAs, server name and minimum version,
+// see http.TLSConfig.
+//
+// If ClientConfig.Url is nil it returns http.UrlEmptyError.
+//
+// If Urls are provided (e.g. accountapi in two regions), they replace Url and calls are routed to the first healthy one.
+// Endpoints failing with network errors or HTTP-5xx are skipped by retries and restored once `/v1/health`
+// (probed every HealthCheckInterval, http.DefaultProbeInterval if it's not provided) responds again.
+// The endpoint which served a call is reported in http.ResponseInfo.Endpoint, Close stops the probing.
+//
+// If ClientConfig.RetriesConfig has any errors, those will be also returned to the caller.
+// If it's nil, retry.DefaultRetriesConfig is used, MaxRetries of 0 disables retries.
+//
+// See New for a constructor with defaults and NewClientFromEnv for configuration from environment variables.
+//
+// RetryPolicy decides which attempts are retried, see http.DefaultRetryPolicy. Create is retried only when it's made
+// with an idempotency key (WithIdempotencyKey option or http.WithIdempotencyKey), it's sent as `Idempotency-Key` header
+// shared by all the attempts. Generated keys are sent as well unless DisableIdempotencyKeys is set.
+//
+// Metadata of every call (status, headers, attempts, elapsed time and the server's request id read from RequestIdHeader)
+// can be captured by passing the context returned by http.WithResponseInfo.
 //
 // If Logging is enabled, every outgoing request will be logged along with its execution time, including retries.
+// Records are written to Logger if it's provided, see http.ClientConfig.
+//
+// If Metrics are provided (e.g. http.PrometheusMetrics), every call is measured under `account.<operation>` name.
+//
+// If Tracer is provided, every call gets a span named after the operation, W3C trace context is always propagated.
+//
+// If Auth is provided (e.g. http.OAuth2ClientCredentials), it will authorise every outgoing request.
+//
+// If Signer is provided (e.g. http.HttpSignatureSigner), it will sign every outgoing request.
+//
+// Middlewares and CallMiddlewares are passed to http.Client, look into http.Middleware for ordering guarantees.
+//
+// If DryRun is enabled, Create and Delete are validated and their requests are built exactly as they would be sent
+// (URL, headers including Auth and Signer ones, body) but returned in http.DryRunError instead of being sent.
+// Those requests are logged if Logging is enabled. It can be overridden per call with DryRun option.
+//
+// If ReadOnly is enabled (e.g. for tooling inspecting production), Create and Delete fail with ReadOnlyError
+// without making any calls.
+//
+// If CoalesceRequests is enabled, concurrent Fetch (or List) calls with the same parameters share one http call
+// and its result, cancelling one of them doesn't abort the call for the others, see http.ClientConfig.
+//
+// If DeletionPolicy is provided (e.g. ProtectAccounts), Delete fetches the account first and doesn't delete it
+// if the policy returns an error, unless the call is made with OverrideDeletionPolicy option.
 func NewClient(config ClientConfig) (*Client, error) {
+	var failover *http.FailoverConfig
+	if len(config.Urls) > 0 {
+		config.Url = config.Urls[0]
+		failover = &http.FailoverConfig{
+			Endpoints:     config.Urls,
+			HealthPath:    "/v1/health",
+			ProbeInterval: config.HealthCheckInterval,
+		}
+	}
+	if config.Url == nil {
+		return nil, http.UrlEmptyError
+	}
 	client, err := http.NewClient(http.ClientConfig{
-		Timeout: config.Timeout,
-		Logging: config.Logging,
-		Retries: config.RetriesConfig,
+		Timeout:                config.Timeout,
+		CallTimeout:            config.CallTimeout,
+		Transport:              config.Transport,
+		TransportConfig:        config.TransportConfig,
+		TLS:                    config.TLS,
+		Failover:               failover,
+		Logging:                config.Logging,
+		Logger:                 config.Logger,
+		Metrics:                config.Metrics,
+		Tracer:                 config.Tracer,
+		Retries:                config.RetriesConfig,
+		RetryPolicy:            config.RetryPolicy,
+		DisableIdempotencyKeys: config.DisableIdempotencyKeys,
+		RequestIdHeader:        config.RequestIdHeader,
+		Auth:                   config.Auth,
+		Signer:                 config.Signer,
+		Middlewares:            config.Middlewares,
+		CallMiddlewares:        config.CallMiddlewares,
+		CoalesceRequests:       config.CoalesceRequests,
 		Headers: http.Headers{
 			"Content-Type": "application/vnd.api+json",
 			"Accept":       "application/vnd.api+json",
@@ -61,17 +162,32 @@ func NewClient(config ClientConfig) (*Client, error) {
 		return nil, err
 	}
 	return &Client{
-		Url:    config.Url,
-		Client: client,
+		Url:            config.Url,
+		Client:         client,
+		DryRun:         config.DryRun,
+		ReadOnly:       config.ReadOnly,
+		DeletionPolicy: config.DeletionPolicy,
 	}, nil
 }
 
+// Releases background resources of the Client, see http.Client.Close
+func (c *Client) Close() {
+	c.Client.Close()
+}
+
 // Creates Account https://api-docs.form3.tech/api.html#organisation-accounts-create
 //
 // In case of network, parsing or io error (non http related) it will return ClientError.
 //
 // In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
-func (c *Client) Create(ctx context.Context, request *CreateAccountRequest) (*CreateAccountResponse, error) {
+//
+// Client-wide settings can be overridden for the call with CallOption, e.g. WithTimeout or WithIdempotencyKey.
+//
+// In dry run (see ClientConfig.DryRun) it returns http.DryRunError with the request which would have been sent.
+func (c *Client) Create(ctx context.Context, request *CreateAccountRequest, opts ...CallOption) (*CreateAccountResponse, error) {
+	if c.ReadOnly {
+		return nil, &ReadOnlyError{Operation: CreateOperation}
+	}
 	err := request.Validate()
 
 	if err != nil {
@@ -83,6 +199,8 @@ func (c *Client) Create(ctx context.Context, request *CreateAccountRequest) (*Cr
 		return nil, err
 	}
 	var createAccountResponse *CreateAccountResponse
+	ctx, cancel := applyOptions(ctx, CreateOperation, c.mutationOptions(opts))
+	defer cancel()
 	err = c.Client.Post(ctx, path, request, &createAccountResponse)
 	return createAccountResponse, err
 }
@@ -94,7 +212,7 @@ func (c *Client) Create(ctx context.Context, request *CreateAccountRequest) (*Cr
 // In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
 //
 // In case of invalid FetchAccountRequest it will return ValidationError
-func (c *Client) Fetch(ctx context.Context, request *FetchAccountRequest) (*FetchAccountResponse, error) {
+func (c *Client) Fetch(ctx context.Context, request *FetchAccountRequest, opts ...CallOption) (*FetchAccountResponse, error) {
 	err := request.Validate()
 
 	if err != nil {
@@ -107,6 +225,8 @@ func (c *Client) Fetch(ctx context.Context, request *FetchAccountRequest) (*Fetc
 	}
 
 	var fetchAccountResponse *FetchAccountResponse
+	ctx, cancel := applyOptions(ctx, FetchOperation, opts)
+	defer cancel()
 	err = c.Client.Get(ctx, path, &fetchAccountResponse)
 	return fetchAccountResponse, err
 }
@@ -118,7 +238,7 @@ func (c *Client) Fetch(ctx context.Context, request *FetchAccountRequest) (*Fetc
 // In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
 //
 // In case of invalid ListAccountsRequest it will return ValidationError
-func (c *Client) List(ctx context.Context, request *ListAccountsRequest) (*ListAccountResponse, error) {
+func (c *Client) List(ctx context.Context, request *ListAccountsRequest, opts ...CallOption) (*ListAccountResponse, error) {
 	err := request.Validate()
 
 	if err != nil {
@@ -135,6 +255,8 @@ func (c *Client) List(ctx context.Context, request *ListAccountsRequest) (*ListA
 	query.Set("page[size]", strconv.Itoa(request.PageSize))
 	path.RawQuery = query.Encode()
 	var listAccountsResponse *ListAccountResponse
+	ctx, cancel := applyOptions(ctx, ListOperation, opts)
+	defer cancel()
 	err = c.Client.Get(ctx, path, &listAccountsResponse)
 	return listAccountsResponse, err
 }
@@ -145,14 +267,39 @@ func (c *Client) List(ctx context.Context, request *ListAccountsRequest) (*ListA
 //
 // In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
 //
+// In case of read-only Client it will return ReadOnlyError, in case of an account protected by ClientConfig.DeletionPolicy
+// it will return the error of the policy (e.g. ProtectedAccountError).
+//
+// In dry run (see ClientConfig.DryRun) it returns http.DryRunError with the request which would have been sent.
+//
 // In case of invalid DeleteAccountRequest it will return ValidationError
-func (c *Client) Delete(ctx context.Context, request *DeleteAccountRequest) error {
+func (c *Client) Delete(ctx context.Context, request *DeleteAccountRequest, opts ...CallOption) error {
+	if c.ReadOnly {
+		return &ReadOnlyError{Operation: DeleteOperation}
+	}
 	err := request.Validate()
 
 	if err != nil {
 		return err
 	}
 
+	if options := resolveOptions(opts); c.DeletionPolicy != nil && !options.overridePolicy {
+		if options.timeout > 0 {
+			// Fetch and delete share the timeout of the call
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, options.timeout)
+			defer cancel()
+			opts = append(opts[:len(opts):len(opts)], WithTimeout(0))
+		}
+		fetched, err := c.Fetch(ctx, &FetchAccountRequest{Id: request.Id}, append(opts[:len(opts):len(opts)], DryRun(false))...)
+		if err != nil {
+			return err
+		}
+		if err := c.DeletionPolicy(fetched.Account); err != nil {
+			return err
+		}
+	}
+
 	path, err := url.ParseRequestURI(fmt.Sprintf("%s/v1/organisation/accounts/%s", c.Url.String(), request.Id))
 	if err != nil {
 		return err
@@ -161,6 +308,16 @@ func (c *Client) Delete(ctx context.Context, request *DeleteAccountRequest) erro
 	query := path.Query()
 	query.Set("version", strconv.Itoa(request.Version))
 	path.RawQuery = query.Encode()
+	ctx, cancel := applyOptions(ctx, DeleteOperation, c.mutationOptions(opts))
+	defer cancel()
 	err = c.Client.Delete(ctx, path)
 	return err
 }
+
+// Prepends client-wide options of mutating calls, so the caller's options take precedence
+func (c *Client) mutationOptions(opts []CallOption) []CallOption {
+	if !c.DryRun {
+		return opts
+	}
+	return append([]CallOption{DryRun(true)}, opts...)
+}
@@ -0,0 +1,256 @@
+package account
+
+import (
+	"accountapi-client/http"
+	"accountapi-client/logging"
+	"accountapi-client/retry"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used by New and NewClientFromEnv
+const (
+	DefaultUrl     = "http://localhost:8080"
+	DefaultTimeout = 10 * time.Second
+)
+
+// Environment variables read by NewClientFromEnv, durations are parsed with time.ParseDuration (e.g. `5s`),
+// UrlEnv may contain comma separated base URLs for failover (see ClientConfig.Urls)
+const (
+	UrlEnv         = "ACCOUNT_API_URL"
+	TimeoutEnv     = "ACCOUNT_API_TIMEOUT"
+	CallTimeoutEnv = "ACCOUNT_API_CALL_TIMEOUT"
+	MaxRetriesEnv  = "ACCOUNT_API_MAX_RETRIES"
+	RetryDelayEnv  = "ACCOUNT_API_RETRY_DELAY"
+	RetryFactorEnv = "ACCOUNT_API_RETRY_FACTOR"
+)
+
+// Modifies ClientConfig built by New
+type ClientOption func(config *ClientConfig) error
+
+// Creates new instance of Client from DefaultUrl, DefaultTimeout and retry.DefaultRetriesConfig,
+// modified by the options which are applied in order. Errors of options and NewClient are returned to the caller.
+//
+//	client, err := account.New(
+//		account.WithBaseUrl("https://accountapi.internal"),
+//		account.WithoutRetries(),
+//		account.WithLogger(logger))
+func New(opts ...ClientOption) (*Client, error) {
+	defaultUrl, _ := url.Parse(DefaultUrl)
+	config := ClientConfig{
+		Url:           defaultUrl,
+		Timeout:       DefaultTimeout,
+		RetriesConfig: retry.DefaultRetriesConfig(),
+	}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+	return NewClient(config)
+}
+
+// Creates new instance of Client like New, with settings read from environment variables (UrlEnv, TimeoutEnv,
+// CallTimeoutEnv, MaxRetriesEnv, RetryDelayEnv and RetryFactorEnv) applied before the options.
+//
+// Variables which are not set keep their defaults, malformed values are returned as errors naming the variable.
+func NewClientFromEnv(opts ...ClientOption) (*Client, error) {
+	return New(append([]ClientOption{fromEnv}, opts...)...)
+}
+
+func fromEnv(config *ClientConfig) error {
+	if value, ok := os.LookupEnv(UrlEnv); ok {
+		rawUrls := strings.Split(value, ",")
+		for i := range rawUrls {
+			rawUrls[i] = strings.TrimSpace(rawUrls[i])
+		}
+		option := WithBaseUrls(rawUrls...)
+		if len(rawUrls) == 1 {
+			option = WithBaseUrl(rawUrls[0])
+		}
+		if err := option(config); err != nil {
+			return fmt.Errorf("%s: %w", UrlEnv, err)
+		}
+	}
+	if err := lookupDuration(TimeoutEnv, &config.Timeout); err != nil {
+		return err
+	}
+	if err := lookupDuration(CallTimeoutEnv, &config.CallTimeout); err != nil {
+		return err
+	}
+
+	retries := *config.RetriesConfig
+	if value, ok := os.LookupEnv(MaxRetriesEnv); ok {
+		maxRetries, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", MaxRetriesEnv, err)
+		}
+		retries.MaxRetries = maxRetries
+	}
+	if err := lookupDuration(RetryDelayEnv, &retries.Delay); err != nil {
+		return err
+	}
+	if value, ok := os.LookupEnv(RetryFactorEnv); ok {
+		factor, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", RetryFactorEnv, err)
+		}
+		retries.Factor = factor
+	}
+	config.RetriesConfig = &retries
+	return nil
+}
+
+func lookupDuration(name string, target *time.Duration) error {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	*target = duration
+	return nil
+}
+
+// Sets base URL of accountapi, e.g. `https://accountapi.internal`, replacing base URLs set by WithBaseUrls
+func WithBaseUrl(rawUrl string) ClientOption {
+	return func(config *ClientConfig) error {
+		baseUrl, err := url.ParseRequestURI(rawUrl)
+		if err != nil {
+			return err
+		}
+		config.Url = baseUrl
+		config.Urls = nil
+		return nil
+	}
+}
+
+// Sets ordered base URLs of accountapi with failover between them, see ClientConfig.Urls
+func WithBaseUrls(rawUrls ...string) ClientOption {
+	return func(config *ClientConfig) error {
+		config.Urls = nil
+		for _, rawUrl := range rawUrls {
+			baseUrl, err := url.ParseRequestURI(rawUrl)
+			if err != nil {
+				return err
+			}
+			config.Urls = append(config.Urls, baseUrl)
+		}
+		return nil
+	}
+}
+
+// Sets timeout of every attempt, see ClientConfig.Timeout
+func WithAttemptTimeout(timeout time.Duration) ClientOption {
+	return func(config *ClientConfig) error {
+		config.Timeout = timeout
+		return nil
+	}
+}
+
+// Sets timeout of whole calls including retries, see ClientConfig.CallTimeout
+func WithCallTimeout(timeout time.Duration) ClientOption {
+	return func(config *ClientConfig) error {
+		config.CallTimeout = timeout
+		return nil
+	}
+}
+
+// Replaces retry.DefaultRetriesConfig
+func WithRetriesConfig(retries retry.RetriesConfig) ClientOption {
+	return func(config *ClientConfig) error {
+		config.RetriesConfig = &retries
+		return nil
+	}
+}
+
+// Disables retries of all the calls
+func WithoutRetries() ClientOption {
+	return WithRetriesConfig(retry.RetriesConfig{MaxRetries: 0})
+}
+
+// Enables logging to the logger, see ClientConfig.Logger
+func WithLogger(logger logging.Logger) ClientOption {
+	return func(config *ClientConfig) error {
+		config.Logger = logger
+		return nil
+	}
+}
+
+// Sets ClientConfig.Metrics
+func WithMetrics(metrics http.Metrics) ClientOption {
+	return func(config *ClientConfig) error {
+		config.Metrics = metrics
+		return nil
+	}
+}
+
+// Sets ClientConfig.Tracer
+func WithTracer(tracer http.Tracer) ClientOption {
+	return func(config *ClientConfig) error {
+		config.Tracer = tracer
+		return nil
+	}
+}
+
+// Sets ClientConfig.Auth
+func WithAuth(auth http.Authenticator) ClientOption {
+	return func(config *ClientConfig) error {
+		config.Auth = auth
+		return nil
+	}
+}
+
+// Sets ClientConfig.TLS
+func WithTLS(tls http.TLSConfig) ClientOption {
+	return func(config *ClientConfig) error {
+		config.TLS = &tls
+		return nil
+	}
+}
+
+// Makes Create and Delete build their requests without sending them, see ClientConfig.DryRun
+func WithDryRun() ClientOption {
+	return func(config *ClientConfig) error {
+		config.DryRun = true
+		return nil
+	}
+}
+
+// Makes Create and Delete fail with ReadOnlyError, see ClientConfig.ReadOnly
+func WithReadOnly() ClientOption {
+	return func(config *ClientConfig) error {
+		config.ReadOnly = true
+		return nil
+	}
+}
+
+// Sets ClientConfig.DeletionPolicy
+func WithDeletionPolicy(policy DeletionPolicy) ClientOption {
+	return func(config *ClientConfig) error {
+		config.DeletionPolicy = policy
+		return nil
+	}
+}
+
+// Makes concurrent identical Fetch and List calls share one http call, see ClientConfig.CoalesceRequests
+func WithCoalescing() ClientOption {
+	return func(config *ClientConfig) error {
+		config.CoalesceRequests = true
+		return nil
+	}
+}
+
+// Modifies any other field of ClientConfig
+func WithConfig(modify func(config *ClientConfig)) ClientOption {
+	return func(config *ClientConfig) error {
+		modify(config)
+		return nil
+	}
+}
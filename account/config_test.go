@@ -0,0 +1,126 @@
+package account
+
+import (
+	"accountapi-client/http"
+	"accountapi-client/retry"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	t.Logf("Given no options")
+
+	t.Logf("When creating Client")
+	client, err := New()
+
+	t.Logf("Should use defaults")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultUrl, client.Url.String())
+
+	t.Logf("When creating Client with options")
+	client, err = New(WithBaseUrl("https://accountapi.internal"), WithoutRetries(), WithAttemptTimeout(time.Second))
+
+	t.Logf("Should apply them")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://accountapi.internal", client.Url.String())
+
+	t.Logf("When creating Client with invalid options")
+	client, err = New(WithBaseUrl("not a url"))
+
+	t.Logf("Should return an error")
+	assert.Error(t, err)
+	assert.Nil(t, client)
+}
+
+func TestNewClientWithDefaultRetries(t *testing.T) {
+	t.Logf("Given ClientConfig without RetriesConfig and Url")
+	config := ClientConfig{Timeout: time.Second}
+
+	t.Logf("When creating Client")
+	client, err := NewClient(config)
+
+	t.Logf("Should return '%s' error", http.UrlEmptyError)
+	assert.Equal(t, http.UrlEmptyError, err)
+	assert.Nil(t, client)
+
+	t.Logf("When creating Client with Url")
+	config.Url, _ = url.Parse(DefaultUrl)
+	client, err = NewClient(config)
+
+	t.Logf("Should not return any errors")
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	t.Logf("Given environment variables")
+	setEnv(t, map[string]string{
+		UrlEnv:         "https://accountapi.internal",
+		TimeoutEnv:     "2s",
+		MaxRetriesEnv:  "0",
+		RetryFactorEnv: "1.5",
+	})
+
+	t.Logf("When creating Client from them")
+	client, err := NewClientFromEnv()
+
+	t.Logf("Should use them")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://accountapi.internal", client.Url.String())
+
+	t.Logf("And should not configure failover for a single URL")
+	assert.Nil(t, client.Client.Failover())
+
+	t.Logf("When the URL is overridden with an option")
+	setEnv(t, map[string]string{UrlEnv: "https://primary.internal, https://secondary.internal"})
+	client, err = NewClientFromEnv(WithBaseUrl("https://explicit.internal"))
+
+	t.Logf("Should use the option")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://explicit.internal", client.Url.String())
+	assert.Nil(t, client.Client.Failover())
+
+	t.Logf("When creating Client from comma separated URLs with spaces")
+	client, err = NewClientFromEnv()
+
+	t.Logf("Should fail over between them")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://primary.internal", client.Url.String())
+	assert.Equal(t, 2, len(client.Client.Failover().Endpoints()))
+	client.Client.Close()
+
+	t.Logf("When one of them is malformed")
+	setEnv(t, map[string]string{RetryDelayEnv: "soon"})
+	client, err = NewClientFromEnv()
+
+	t.Logf("Should return an error naming it")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), RetryDelayEnv)
+	assert.Nil(t, client)
+
+	t.Logf("When retries are negative")
+	setEnv(t, map[string]string{RetryDelayEnv: "1s", MaxRetriesEnv: "-1"})
+	client, err = NewClientFromEnv()
+
+	t.Logf("Should return '%s' error", retry.MaxRetriesNegativeError)
+	assert.Equal(t, retry.MaxRetriesNegativeError, err)
+}
+
+// Sets environment variables restoring their previous values once the test is finished
+func setEnv(t *testing.T, values map[string]string) {
+	for name, value := range values {
+		name := name
+		previous, ok := os.LookupEnv(name)
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+		os.Setenv(name, value)
+	}
+}
@@ -0,0 +1,28 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accounttest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestContractWithApi(t *testing.T) {
+	apiPath := os.Getenv("ACCOUNT_API_URL")
+	if len(apiPath) == 0 {
+		t.Skip("ACCOUNT_API_URL is not set")
+	}
+	accounttest.RunContract(t, func(t *testing.T) account.AccountService {
+		apiUrl, err := url.Parse(apiPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client, err := account.NewClient(account.ClientConfig{Timeout: time.Second, Url: apiUrl})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	})
+}
@@ -0,0 +1,211 @@
+package account
+
+import (
+	"accountapi-client/http"
+	"accountapi-client/logging"
+	"context"
+	"errors"
+	"time"
+)
+
+// Names of operations, used by http.Metrics, OperationMetrics, tracing spans and decorators
+const (
+	CreateOperation = "account.create"
+	FetchOperation  = "account.fetch"
+	ListOperation   = "account.list"
+	DeleteOperation = "account.delete"
+	HealthOperation = "account.health"
+)
+
+// Wraps AccountService with account-level behaviour, e.g. LoggingDecorator, CachingDecorator, ReadOnlyDecorator,
+// DryRunDecorator or AuditDecorator
+type Decorator func(next AccountService) AccountService
+
+// Wraps the service with decorators, the first one is the outermost:
+//
+//	service := account.Chain(client, account.LoggingDecorator(logger), account.CachingDecorator(time.Minute))
+func Chain(service AccountService, decorators ...Decorator) AccountService {
+	for i := len(decorators) - 1; i >= 0; i-- {
+		service = decorators[i](service)
+	}
+	return service
+}
+
+// Describes a call of AccountService seen by an Interceptor
+type Call struct {
+	Operation string
+	// Empty for List and Health
+	AccountId string
+	// *CreateAccountRequest, *FetchAccountRequest, *ListAccountsRequest, *DeleteAccountRequest or nil for Health
+	Request interface{}
+	Options []CallOption
+}
+
+// Runs around every call, it should call next to proceed with it (possibly with a modified context)
+// or return an error to reject it
+type Interceptor func(ctx context.Context, call Call, next func(ctx context.Context) error) error
+
+// Creates a Decorator running the interceptor around every operation
+func Intercept(interceptor Interceptor) Decorator {
+	return func(next AccountService) AccountService {
+		return &intercepted{next: next, interceptor: interceptor}
+	}
+}
+
+type intercepted struct {
+	next        AccountService
+	interceptor Interceptor
+}
+
+func (s *intercepted) Create(ctx context.Context, request *CreateAccountRequest, opts ...CallOption) (*CreateAccountResponse, error) {
+	var response *CreateAccountResponse
+	call := Call{Operation: CreateOperation, Request: request, Options: opts}
+	if request != nil && request.Account != nil {
+		call.AccountId = request.Account.Id
+	}
+	err := s.interceptor(ctx, call, func(ctx context.Context) (err error) {
+		response, err = s.next.Create(ctx, request, opts...)
+		return err
+	})
+	return response, err
+}
+
+func (s *intercepted) Fetch(ctx context.Context, request *FetchAccountRequest, opts ...CallOption) (*FetchAccountResponse, error) {
+	var response *FetchAccountResponse
+	call := Call{Operation: FetchOperation, Request: request, Options: opts}
+	if request != nil {
+		call.AccountId = request.Id
+	}
+	err := s.interceptor(ctx, call, func(ctx context.Context) (err error) {
+		response, err = s.next.Fetch(ctx, request, opts...)
+		return err
+	})
+	return response, err
+}
+
+func (s *intercepted) List(ctx context.Context, request *ListAccountsRequest, opts ...CallOption) (*ListAccountResponse, error) {
+	var response *ListAccountResponse
+	call := Call{Operation: ListOperation, Request: request, Options: opts}
+	err := s.interceptor(ctx, call, func(ctx context.Context) (err error) {
+		response, err = s.next.List(ctx, request, opts...)
+		return err
+	})
+	return response, err
+}
+
+func (s *intercepted) Delete(ctx context.Context, request *DeleteAccountRequest, opts ...CallOption) error {
+	call := Call{Operation: DeleteOperation, Request: request, Options: opts}
+	if request != nil {
+		call.AccountId = request.Id
+	}
+	return s.interceptor(ctx, call, func(ctx context.Context) error {
+		return s.next.Delete(ctx, request, opts...)
+	})
+}
+
+func (s *intercepted) Health(ctx context.Context, opts ...CallOption) (*Health, error) {
+	var response *Health
+	call := Call{Operation: HealthOperation, Options: opts}
+	err := s.interceptor(ctx, call, func(ctx context.Context) (err error) {
+		response, err = s.next.Health(ctx, opts...)
+		return err
+	})
+	return response, err
+}
+
+// Logs every operation along with its account id, outcome and latency, requests are not logged as they contain PII
+// and errors are redacted with logging.Redact
+func LoggingDecorator(logger logging.Logger) Decorator {
+	return Intercept(func(ctx context.Context, call Call, next func(ctx context.Context) error) error {
+		startTime := time.Now()
+		err := next(ctx)
+		fields := []logging.Field{
+			logging.F("operation", call.Operation),
+			logging.F("latency", time.Now().Sub(startTime)),
+		}
+		if len(call.AccountId) > 0 {
+			fields = append(fields, logging.F("account_id", call.AccountId))
+		}
+		if err != nil {
+			logger.Log(logging.Warn, "Account operation failed", append(fields, logging.F("error", logging.Redact(err.Error())))...)
+		} else {
+			logger.Log(logging.Info, "Account operation completed", fields...)
+		}
+		return err
+	})
+}
+
+// Receives measurements of MetricsDecorator, implementations have to be safe for concurrent use,
+// e.g. http.PrometheusMetrics
+type OperationMetrics interface {
+	// Called once per operation with its latency, outcome is one of `ok`, `validation`, `blocked`, `dry_run`,
+	// `4xx`, `5xx` or `error`
+	ObserveOperation(operation string, outcome string, latency time.Duration)
+}
+
+// Measures every operation with OperationMetrics under the operation name.
+// Unlike http.Metrics of the Client it counts calls which never reach the network, e.g. rejected by validation,
+// blocked by ClientConfig.ReadOnly or served by CachingDecorator.
+func MetricsDecorator(metrics OperationMetrics) Decorator {
+	return Intercept(func(ctx context.Context, call Call, next func(ctx context.Context) error) error {
+		startTime := time.Now()
+		err := next(ctx)
+		metrics.ObserveOperation(call.Operation, outcome(err), time.Now().Sub(startTime))
+		return err
+	})
+}
+
+func outcome(err error) string {
+	var validationError *ValidationError
+	var readOnlyError *ReadOnlyError
+	var protectedError *ProtectedAccountError
+	var dryRunError *http.DryRunError
+	var httpError *http.ClientHttpError
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.As(err, &validationError):
+		return "validation"
+	case errors.As(err, &readOnlyError), errors.As(err, &protectedError):
+		return "blocked"
+	case errors.As(err, &dryRunError):
+		return "dry_run"
+	case errors.As(err, &httpError) && httpError.StatusCode >= 500:
+		return "5xx"
+	case errors.As(err, &httpError):
+		return "4xx"
+	default:
+		return "error"
+	}
+}
+
+// Refuses Create and Delete with ReadOnlyError without calling the service, other operations are passed through.
+// Unlike ClientConfig.ReadOnly it can guard any AccountService.
+func ReadOnlyDecorator() Decorator {
+	return Intercept(func(ctx context.Context, call Call, next func(ctx context.Context) error) error {
+		if call.Operation == CreateOperation || call.Operation == DeleteOperation {
+			return &ReadOnlyError{Operation: call.Operation}
+		}
+		return next(ctx)
+	})
+}
+
+// Makes Create and Delete dry runs by passing DryRun option to the service, so Client returns http.DryRunError
+// with the request which would have been sent. Like ClientConfig.DryRun it can be overridden per call with DryRun(false).
+func DryRunDecorator() Decorator {
+	return func(next AccountService) AccountService {
+		return &dryRunService{AccountService: next}
+	}
+}
+
+type dryRunService struct {
+	AccountService
+}
+
+func (s *dryRunService) Create(ctx context.Context, request *CreateAccountRequest, opts ...CallOption) (*CreateAccountResponse, error) {
+	return s.AccountService.Create(ctx, request, append([]CallOption{DryRun(true)}, opts...)...)
+}
+
+func (s *dryRunService) Delete(ctx context.Context, request *DeleteAccountRequest, opts ...CallOption) error {
+	return s.AccountService.Delete(ctx, request, append([]CallOption{DryRun(true)}, opts...)...)
+}
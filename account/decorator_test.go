@@ -0,0 +1,253 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"accountapi-client/account/accountmock"
+	"accountapi-client/account/accounttest"
+	"accountapi-client/http"
+	"accountapi-client/logging"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	t.Logf("Given decorators recording the order they run in")
+	var order []string
+	recording := func(name string) account.Decorator {
+		return account.Intercept(func(ctx context.Context, call account.Call, next func(ctx context.Context) error) error {
+			order = append(order, name+" "+call.Operation+" "+call.AccountId)
+			return next(ctx)
+		})
+	}
+	mock := accountmock.New()
+	mock.On(accountmock.Delete).Return(nil, nil)
+
+	t.Logf("When chaining them and deleting an account")
+	service := account.Chain(mock, recording("first"), recording("second"))
+	err := service.Delete(context.Background(), &account.DeleteAccountRequest{Id: "1"})
+
+	t.Logf("Should run the first one as the outermost")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first account.delete 1", "second account.delete 1"}, order)
+	assert.Equal(t, 1, len(mock.Calls(accountmock.Delete)))
+}
+
+func TestLoggingAndMetricsDecorators(t *testing.T) {
+	t.Logf("Given service failing fetches with 404")
+	mock := accountmock.New()
+	mock.On(accountmock.Fetch).Return(nil, &http.ClientHttpError{StatusCode: 404, ResponseBody: []byte(`{"iban":"GB33BUKB20201555555555"}`)})
+	mock.On(accountmock.List).Return(&account.ListAccountResponse{}, nil)
+
+	t.Logf("And given logging and metrics decorators")
+	var records []string
+	var errorField interface{}
+	logger := logging.LoggerFunc(func(level logging.Level, message string, fields ...logging.Field) {
+		records = append(records, message)
+		for _, field := range fields {
+			if field.Key == "error" {
+				errorField = field.Value
+			}
+		}
+	})
+	metrics := http.NewPrometheusMetrics("test")
+	service := account.Chain(mock, account.LoggingDecorator(logger), account.MetricsDecorator(metrics))
+
+	t.Logf("When fetching and listing accounts")
+	service.Fetch(context.Background(), &account.FetchAccountRequest{Id: "1"})
+	service.List(context.Background(), &account.ListAccountsRequest{PageSize: 1})
+
+	t.Logf("Should log and measure both operations")
+	assert.Equal(t, []string{"Account operation failed", "Account operation completed"}, records)
+	assert.Contains(t, errorField, "404")
+	assert.NotContains(t, errorField, "GB33BUKB20201555555555")
+	exposed := metrics.String()
+	assert.True(t, strings.Contains(exposed, `test_service_operations_total{operation="account.fetch",outcome="4xx"} 1`), exposed)
+	assert.True(t, strings.Contains(exposed, `test_service_operations_total{operation="account.list",outcome="ok"} 1`), exposed)
+	assert.False(t, strings.Contains(exposed, `test_http_client_requests_total{`), exposed)
+}
+
+func TestCachingDecorator(t *testing.T) {
+	t.Logf("Given service with an account")
+	id := "ad27b0e5-8e82-4a4d-a0c1-2ae8ab7a8b4e"
+	mock := accountmock.New()
+	mock.On(accountmock.Fetch, accountmock.Id(id)).
+		Return(&account.FetchAccountResponse{Account: &account.Account{Id: id, Attributes: &account.Attributes{Country: "GB"}}}, nil)
+	mock.On(accountmock.Delete).Return(nil, nil)
+
+	t.Logf("And given caching decorator")
+	service := account.Chain(mock, account.CachingDecorator(time.Minute))
+
+	t.Logf("When fetching the account twice and modifying the first response")
+	first, _ := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+	first.Account.Attributes.Country = "PL"
+	second, err := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+
+	t.Logf("Should serve the second one from the cache unaffected")
+	assert.NoError(t, err)
+	assert.Equal(t, "GB", second.Account.Attributes.Country)
+	assert.Equal(t, 1, len(mock.Calls(accountmock.Fetch)))
+
+	t.Logf("When fetching the account with options")
+	var info http.ResponseInfo
+	service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id}, account.WithResponseInfo(&info))
+
+	t.Logf("Should fetch it from the service with the options")
+	fetches := mock.Calls(accountmock.Fetch)
+	assert.Equal(t, 2, len(fetches))
+	assert.Equal(t, 1, len(fetches[1].Options))
+
+	t.Logf("When deleting the account and fetching it again")
+	service.Delete(context.Background(), &account.DeleteAccountRequest{Id: id})
+	service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+
+	t.Logf("Should fetch it from the service")
+	assert.Equal(t, 3, len(mock.Calls(accountmock.Fetch)))
+}
+
+func TestCachingDecoratorWithDeleteDuringFetch(t *testing.T) {
+	t.Logf("Given service with an account")
+	id := "ad27b0e5-8e82-4a4d-a0c1-2ae8ab7a8b4e"
+	mock := accountmock.New()
+	mock.On(accountmock.Fetch, accountmock.Id(id)).
+		Return(&account.FetchAccountResponse{Account: &account.Account{Id: id}}, nil)
+	mock.On(accountmock.Delete).Return(nil, nil)
+
+	t.Logf("And given caching decorator over a service deleting the account while the first fetch is in flight")
+	var service account.AccountService
+	deleted := false
+	deleteDuringFetch := account.Intercept(func(ctx context.Context, call account.Call, next func(ctx context.Context) error) error {
+		err := next(ctx)
+		if call.Operation == account.FetchOperation && !deleted {
+			deleted = true
+			service.Delete(ctx, &account.DeleteAccountRequest{Id: id})
+		}
+		return err
+	})
+	service = account.Chain(mock, account.CachingDecorator(time.Minute), deleteDuringFetch)
+
+	t.Logf("When fetching the account twice")
+	service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+	service.Fetch(context.Background(), &account.FetchAccountRequest{Id: id})
+
+	t.Logf("Should not cache the response of the fetch which was in flight during delete")
+	assert.Equal(t, 2, len(mock.Calls(accountmock.Fetch)))
+	assert.Equal(t, 1, len(mock.Calls(accountmock.Delete)))
+}
+
+func TestAuditDecorator(t *testing.T) {
+	t.Logf("Given service creating accounts and failing deletes with 409")
+	id := "ad27b0e5-8e82-4a4d-a0c1-2ae8ab7a8b4e"
+	mock := accountmock.New()
+	mock.On(accountmock.Create).Return(&account.CreateAccountResponse{Account: &account.Account{Id: id, Version: 0}}, nil)
+	mock.On(accountmock.Delete).Return(nil, &http.ClientHttpError{StatusCode: 409, ResponseBody: []byte(`{"iban":"GB33BUKB20201555555555"}`)})
+
+	t.Logf("And given audit decorator recording to memory")
+	var records []account.AuditRecord
+	service := account.Chain(mock, account.AuditDecorator(account.AuditSinkFunc(func(ctx context.Context, record account.AuditRecord) error {
+		records = append(records, record)
+		return nil
+	})))
+
+	t.Logf("When creating and deleting the account on behalf of a principal")
+	ctx := account.WithPrincipal(context.Background(), "jan@example.com")
+	service.Create(ctx, &account.CreateAccountRequest{Account: &account.Account{Id: id}})
+	service.Delete(ctx, &account.DeleteAccountRequest{Id: id, Version: 3})
+	service.Fetch(ctx, &account.FetchAccountRequest{Id: id})
+
+	t.Logf("Should record only the mutations")
+	assert.Equal(t, 2, len(records))
+	zero, three := 0, 3
+	assert.Equal(t, "jan@example.com", records[0].Principal)
+	assert.Equal(t, account.CreateOperation, records[0].Operation)
+	assert.Equal(t, id, records[0].AccountId)
+	assert.Nil(t, records[0].VersionBefore)
+	assert.Equal(t, &zero, records[0].VersionAfter)
+	assert.Equal(t, "ok", records[0].Outcome)
+	assert.Equal(t, 64, len(records[0].RequestHash))
+	assert.False(t, records[0].Time.IsZero())
+
+	assert.Equal(t, account.DeleteOperation, records[1].Operation)
+	assert.Equal(t, &three, records[1].VersionBefore)
+	assert.Nil(t, records[1].VersionAfter)
+	assert.Equal(t, "4xx", records[1].Outcome)
+	assert.NotEmpty(t, records[1].Error)
+	assert.NotContains(t, records[1].Error, "GB33BUKB20201555555555")
+	assert.NotEqual(t, records[0].RequestHash, records[1].RequestHash)
+}
+
+func TestAuditDecoratorWithFailingSink(t *testing.T) {
+	t.Logf("Given service creating accounts")
+	mock := accountmock.New()
+	created := &account.CreateAccountResponse{Account: &account.Account{Id: "1"}}
+	mock.On(accountmock.Create).Return(created, nil)
+
+	t.Logf("And given audit decorator with failing sink")
+	sinkErr := errors.New("disk full")
+	service := account.Chain(mock, account.AuditDecorator(account.AuditSinkFunc(func(ctx context.Context, record account.AuditRecord) error {
+		return sinkErr
+	})))
+
+	t.Logf("When creating the account")
+	response, err := service.Create(context.Background(), &account.CreateAccountRequest{Account: &account.Account{Id: "1"}})
+
+	t.Logf("Should return AuditError along with the response")
+	var auditError *account.AuditError
+	assert.True(t, errors.As(err, &auditError))
+	assert.True(t, errors.Is(err, sinkErr))
+	assert.Equal(t, "ok", auditError.Record.Outcome)
+	assert.Equal(t, created, response)
+}
+
+func TestReadOnlyDecorator(t *testing.T) {
+	t.Logf("Given service")
+	mock := accountmock.New()
+	mock.On(accountmock.Fetch).Return(&account.FetchAccountResponse{}, nil)
+
+	t.Logf("And given read-only decorator")
+	service := account.Chain(mock, account.ReadOnlyDecorator())
+
+	t.Logf("When creating, deleting and fetching accounts")
+	_, createErr := service.Create(context.Background(), &account.CreateAccountRequest{})
+	deleteErr := service.Delete(context.Background(), &account.DeleteAccountRequest{Id: "1"})
+	_, fetchErr := service.Fetch(context.Background(), &account.FetchAccountRequest{Id: "1"})
+
+	t.Logf("Should refuse the mutations without calling the service")
+	assert.Equal(t, &account.ReadOnlyError{Operation: account.CreateOperation}, createErr)
+	assert.Equal(t, &account.ReadOnlyError{Operation: account.DeleteOperation}, deleteErr)
+	assert.NoError(t, fetchErr)
+	assert.Equal(t, 1, len(mock.Calls()))
+}
+
+func TestDryRunDecorator(t *testing.T) {
+	t.Logf("Given fake server")
+	server := accountfake.NewServer()
+	defer server.Close()
+
+	t.Logf("And given account client wrapped with dry-run decorator")
+	service := account.Chain(server.NewClient(), account.DryRunDecorator())
+
+	t.Logf("When creating and deleting accounts")
+	request := accounttest.NewCreateAccountRequest()
+	_, createErr := service.Create(context.Background(), request)
+	deleteErr := service.Delete(context.Background(), &account.DeleteAccountRequest{Id: request.Account.Id})
+
+	t.Logf("Should return the requests without sending them")
+	var dryRunError *http.DryRunError
+	assert.True(t, errors.As(createErr, &dryRunError))
+	assert.Equal(t, "POST", dryRunError.Method)
+	assert.True(t, errors.As(deleteErr, &dryRunError))
+	assert.Equal(t, "DELETE", dryRunError.Method)
+	assert.Equal(t, 0, len(server.Requests()))
+
+	t.Logf("When creating an account with dry run disabled for the call")
+	_, err := service.Create(context.Background(), request, account.DryRun(false))
+
+	t.Logf("Should send it")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(server.Accounts()))
+}
@@ -0,0 +1,64 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"accountapi-client/account/accounttest"
+	"accountapi-client/http"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMutationsWithDryRun(t *testing.T) {
+	t.Logf("Given fake server with an account")
+	server := accountfake.NewServer()
+	defer server.Close()
+	created, _ := server.NewClient().Create(context.Background(), accounttest.NewCreateAccountRequest())
+
+	t.Logf("And given account client in dry run")
+	client := server.NewClient()
+	client.DryRun = true
+
+	t.Logf("When creating and deleting accounts")
+	request := accounttest.NewCreateAccountRequest()
+	response, createErr := client.Create(context.Background(), request, account.WithIdempotencyKey("a1b2"))
+	deleteErr := client.Delete(context.Background(), &account.DeleteAccountRequest{Id: created.Account.Id, Version: 0})
+
+	t.Logf("Should return requests which would have been sent without sending them")
+	var createRequest *http.DryRunError
+	assert.True(t, errors.As(createErr, &createRequest))
+	assert.Nil(t, response)
+	assert.Equal(t, "POST", createRequest.Method)
+	assert.Equal(t, server.Url().String()+"/v1/organisation/accounts", createRequest.Url.String())
+	assert.Equal(t, "application/vnd.api+json", createRequest.Header.Get("Content-Type"))
+	assert.Equal(t, "a1b2", createRequest.Header.Get("Idempotency-Key"))
+	var body account.CreateAccountRequest
+	assert.NoError(t, json.Unmarshal(createRequest.Body, &body))
+	assert.Equal(t, request.Account.Id, body.Account.Id)
+
+	var deleteRequest *http.DryRunError
+	assert.True(t, errors.As(deleteErr, &deleteRequest))
+	assert.Equal(t, "DELETE", deleteRequest.Method)
+	assert.Equal(t, server.Url().String()+"/v1/organisation/accounts/"+created.Account.Id+"?version=0", deleteRequest.Url.String())
+	assert.Equal(t, 1, len(server.Requests()))
+	assert.Equal(t, 1, len(server.Accounts()))
+
+	t.Logf("When creating an invalid account")
+	_, err := client.Create(context.Background(), &account.CreateAccountRequest{Account: &account.Account{Id: "invalid"}})
+
+	t.Logf("Should return validation error")
+	var validationError *account.ValidationError
+	assert.True(t, errors.As(err, &validationError))
+
+	t.Logf("When fetching the account and deleting it with dry run disabled")
+	_, fetchErr := client.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Account.Id})
+	deleteErr = client.Delete(context.Background(), &account.DeleteAccountRequest{Id: created.Account.Id, Version: 0}, account.DryRun(false))
+
+	t.Logf("Should send them")
+	assert.NoError(t, fetchErr)
+	assert.NoError(t, deleteErr)
+	assert.Equal(t, 0, len(server.Accounts()))
+}
@@ -1,5 +1,7 @@
 package account
 
+import "fmt"
+
 // Throw by the Client on validation issues done before sending any http requests
 type ValidationError struct {
 	Message string
@@ -8,3 +10,23 @@ type ValidationError struct {
 func (e *ValidationError) Error() string {
 	return e.Message
 }
+
+// Returned by Create and Delete of a read-only Client, see ClientConfig.ReadOnly
+type ReadOnlyError struct {
+	Operation string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s is not allowed by a read-only client", e.Operation)
+}
+
+// Returned by Delete when the account is protected by ClientConfig.DeletionPolicy
+type ProtectedAccountError struct {
+	AccountId string
+	// Rule which protects the account, e.g. `status confirmed`
+	Rule string
+}
+
+func (e *ProtectedAccountError) Error() string {
+	return fmt.Sprintf("account %s cannot be deleted as it's protected by rule %s", e.AccountId, e.Rule)
+}
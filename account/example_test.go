@@ -28,6 +28,23 @@ func ExampleNewClient() {
 	account.NewClient(config)
 }
 
+func ExampleNew() {
+	// Create new client with defaults overridden by options
+	client, err := account.New(
+		account.WithBaseUrl("http://localhost:8080"),
+		account.WithAttemptTimeout(time.Second),
+		account.WithRetriesConfig(retry.RetriesConfig{MaxRetries: 3, Delay: time.Second, Factor: 1.5}))
+
+	log.Print(client, err)
+}
+
+func ExampleNewClientFromEnv() {
+	// Reads ACCOUNT_API_URL, ACCOUNT_API_TIMEOUT, ACCOUNT_API_MAX_RETRIES etc.
+	client, err := account.NewClientFromEnv(account.WithoutRetries())
+
+	log.Print(client, err)
+}
+
 func ExampleClient_Fetch() {
 	// Create config
 	config := account.ClientConfig{
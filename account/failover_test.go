@@ -0,0 +1,41 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"accountapi-client/account/accounttest"
+	"accountapi-client/http"
+	"accountapi-client/retry"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestFetchWithFailover(t *testing.T) {
+	t.Logf("Given unavailable primary and fake secondary server with an account")
+	primary := accountfake.NewServer()
+	primary.Close()
+	secondary := accountfake.NewServer()
+	defer secondary.Close()
+	created, _ := secondary.NewClient().Create(context.Background(), accounttest.NewCreateAccountRequest())
+
+	t.Logf("And given account client with both of them")
+	client, _ := account.NewClient(account.ClientConfig{
+		Timeout:       time.Second,
+		Urls:          []*url.URL{primary.Url(), secondary.Url()},
+		RetriesConfig: &retry.RetriesConfig{MaxRetries: 3, Delay: time.Millisecond, Factor: 2},
+	})
+	defer client.Close()
+
+	t.Logf("When fetching the account")
+	var info http.ResponseInfo
+	response, err := client.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Account.Id}, account.WithResponseInfo(&info))
+
+	t.Logf("Should be served by the secondary")
+	assert.NoError(t, err)
+	assert.Equal(t, created.Account.Id, response.Account.Id)
+	assert.Equal(t, secondary.Url(), info.Endpoint)
+	assert.Equal(t, 2, info.Attempts)
+}
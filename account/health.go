@@ -0,0 +1,134 @@
+package account
+
+import (
+	"accountapi-client/http"
+	"context"
+	"encoding/json"
+	"fmt"
+	corehttp "net/http"
+	"net/url"
+	"time"
+)
+
+// Status of accountapi reported by Client.Health
+type HealthStatus string
+
+const (
+	HealthUp   HealthStatus = "up"
+	HealthDown HealthStatus = "down"
+)
+
+// Result of the health check, Status is HealthDown whenever accountapi couldn't be reached or responded with an error
+type Health struct {
+	Status  HealthStatus  `json:"status"`
+	Latency time.Duration `json:"-"`
+	// Base URL which served the check
+	Endpoint string `json:"endpoint,omitempty"`
+	Error    string `json:"error,omitempty"`
+	// States of failover endpoints if ClientConfig.Urls are configured
+	Endpoints []EndpointHealth `json:"endpoints,omitempty"`
+}
+
+type EndpointHealth struct {
+	Url     string `json:"url"`
+	Healthy bool   `json:"healthy"`
+	// Time of the last failure, nil if the endpoint never failed
+	FailedAt *time.Time `json:"failed_at,omitempty"`
+}
+
+// Checks health of accountapi https://api-docs.form3.tech/api.html#system-health, the check is never retried.
+//
+// Health is always returned, along with ClientError or ClientHttpError if accountapi couldn't be reached
+// or responded with an error.
+func (c *Client) Health(ctx context.Context, opts ...CallOption) (*Health, error) {
+	path, err := url.ParseRequestURI(fmt.Sprintf("%s/v1/health", c.Url.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	var info http.ResponseInfo
+	opts = append([]CallOption{NoRetry(), WithResponseInfo(&info)}, opts...)
+	ctx, cancel := applyOptions(ctx, HealthOperation, opts)
+	defer cancel()
+
+	var response struct {
+		Status HealthStatus `json:"status"`
+	}
+	err = c.Client.Get(ctx, path, &response)
+
+	health := &Health{Status: response.Status, Latency: info.Elapsed, Endpoints: c.endpoints()}
+	if info.Endpoint != nil {
+		health.Endpoint = info.Endpoint.String()
+	}
+	if err != nil {
+		health.Status = HealthDown
+		health.Error = err.Error()
+	} else if len(health.Status) == 0 {
+		health.Status = HealthUp
+	}
+	return health, err
+}
+
+func (c *Client) endpoints() []EndpointHealth {
+	failover := c.Client.Failover()
+	if failover == nil {
+		return nil
+	}
+	var endpoints []EndpointHealth
+	for _, endpoint := range failover.Endpoints() {
+		endpointHealth := EndpointHealth{Url: endpoint.Url.String(), Healthy: endpoint.Healthy}
+		if !endpoint.FailedAt.IsZero() {
+			failedAt := endpoint.FailedAt
+			endpointHealth.FailedAt = &failedAt
+		}
+		endpoints = append(endpoints, endpointHealth)
+	}
+	return endpoints
+}
+
+// Returns a handler which services can mount as their readiness probe. Every request checks health of accountapi
+// (limited by the timeout if it's above zero) and combines it with the client-side state of failover endpoints
+// (ClientConfig.Urls). It responds with 200 if accountapi is up and at least one of the endpoints is healthy,
+// 503 otherwise, the body is JSON:
+//
+//	{"ready": true, "status": "up", "latency_ms": 12, "endpoint": "http://localhost:8080", "healthy_endpoints": 1, "endpoints": [...]}
+//
+// healthy_endpoints is present only if failover endpoints are configured.
+func (c *Client) ReadinessHandler(timeout time.Duration) corehttp.Handler {
+	return corehttp.HandlerFunc(func(response corehttp.ResponseWriter, request *corehttp.Request) {
+		var opts []CallOption
+		if timeout > 0 {
+			opts = append(opts, WithTimeout(timeout))
+		}
+		health, _ := c.Health(request.Context(), opts...)
+
+		body := struct {
+			Ready bool `json:"ready"`
+			*Health
+			LatencyMs        int64 `json:"latency_ms"`
+			HealthyEndpoints *int  `json:"healthy_endpoints,omitempty"`
+		}{Health: health}
+		if health != nil {
+			body.Ready = health.Status == HealthUp
+			body.LatencyMs = health.Latency.Milliseconds()
+			if len(health.Endpoints) > 0 {
+				healthy := 0
+				for _, endpoint := range health.Endpoints {
+					if endpoint.Healthy {
+						healthy++
+					}
+				}
+				body.HealthyEndpoints = &healthy
+				body.Ready = body.Ready && healthy > 0
+			}
+		}
+
+		statusCode := corehttp.StatusOK
+		if !body.Ready {
+			statusCode = corehttp.StatusServiceUnavailable
+		}
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(statusCode)
+		json.NewEncoder(response).Encode(body)
+	})
+}
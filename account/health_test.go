@@ -0,0 +1,82 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	t.Logf("Given fake server")
+	server := accountfake.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+
+	t.Logf("When checking health")
+	health, err := client.Health(context.Background())
+
+	t.Logf("Should report it's up")
+	assert.NoError(t, err)
+	assert.Equal(t, account.HealthUp, health.Status)
+	assert.True(t, health.Latency > 0)
+
+	t.Logf("When the server is unavailable")
+	server.DropResponses(503, 2)
+	health, err = client.Health(context.Background())
+
+	t.Logf("Should report it's down without retrying")
+	assert.Error(t, err)
+	assert.Equal(t, account.HealthDown, health.Status)
+	assert.NotEmpty(t, health.Error)
+	assert.Equal(t, 2, len(server.Requests()))
+}
+
+func TestReadinessHandler(t *testing.T) {
+	t.Logf("Given unavailable primary and fake secondary server")
+	primary := accountfake.NewServer()
+	primary.Close()
+	secondary := accountfake.NewServer()
+	defer secondary.Close()
+
+	t.Logf("And given account client with both of them")
+	client, _ := account.NewClient(account.ClientConfig{
+		Timeout: time.Second,
+		Urls:    []*url.URL{primary.Url(), secondary.Url()},
+	})
+	defer client.Close()
+	handler := client.ReadinessHandler(time.Second)
+
+	t.Logf("When probing readiness twice")
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ready", nil))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/ready", nil))
+
+	t.Logf("Should report it's ready along with states of the endpoints")
+	assert.Equal(t, 200, recorder.Code)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, true, body["ready"])
+	assert.Equal(t, "up", body["status"])
+	assert.Equal(t, float64(1), body["healthy_endpoints"])
+	assert.Equal(t, secondary.Url().String(), body["endpoint"])
+	endpoints := body["endpoints"].([]interface{})
+	assert.Equal(t, false, endpoints[0].(map[string]interface{})["healthy"])
+	assert.Equal(t, true, endpoints[1].(map[string]interface{})["healthy"])
+
+	t.Logf("When all the endpoints are unavailable")
+	secondary.DropResponses(503, 1)
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/ready", nil))
+
+	t.Logf("Should report it's not ready along with no healthy endpoints")
+	assert.Equal(t, 503, recorder.Code)
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	assert.Equal(t, false, body["ready"])
+	assert.Equal(t, float64(0), body["healthy_endpoints"])
+}
@@ -0,0 +1,124 @@
+package account
+
+import (
+	"accountapi-client/http"
+	"accountapi-client/retry"
+	"context"
+	"time"
+)
+
+// Overrides client-wide settings for a single call of Create, Fetch, List or Delete
+type CallOption func(options *callOptions)
+
+type callOptions struct {
+	timeout        time.Duration
+	retries        *retry.RetriesConfig
+	retryPolicy    http.RetryPolicy
+	headers        http.Headers
+	idempotencyKey string
+	responseInfo   *http.ResponseInfo
+	dryRun         *bool
+	overridePolicy bool
+}
+
+// Limits the whole call including retries and delays between them
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(options *callOptions) {
+		options.timeout = timeout
+	}
+}
+
+// Replaces ClientConfig.RetriesConfig for the call, errors of retry.NewRetries are returned by the call
+func WithRetries(config retry.RetriesConfig) CallOption {
+	return func(options *callOptions) {
+		options.retries = &config
+	}
+}
+
+// Replaces ClientConfig.RetryPolicy for the call
+func WithRetryPolicy(policy http.RetryPolicy) CallOption {
+	return func(options *callOptions) {
+		options.retryPolicy = policy
+	}
+}
+
+// Disables retries of the call
+func NoRetry() CallOption {
+	return WithRetryPolicy(http.NoRetryPolicy)
+}
+
+// Sets the header on the request of the call, it takes precedence over default headers of the Client
+func WithHeader(key string, value string) CallOption {
+	return func(options *callOptions) {
+		if options.headers == nil {
+			options.headers = http.Headers{}
+		}
+		options.headers[key] = value
+	}
+}
+
+// Sends the key as `Idempotency-Key` header of Create instead of a generated one
+func WithIdempotencyKey(key string) CallOption {
+	return func(options *callOptions) {
+		options.idempotencyKey = key
+	}
+}
+
+// Fills info with metadata of the call once it's finished, see http.ResponseInfo
+func WithResponseInfo(info *http.ResponseInfo) CallOption {
+	return func(options *callOptions) {
+		options.responseInfo = info
+	}
+}
+
+// Builds the request of the call but doesn't send it, the call returns http.DryRunError carrying it.
+// It overrides ClientConfig.DryRun, so DryRun(false) sends a request of a dry-run Client.
+func DryRun(dryRun bool) CallOption {
+	return func(options *callOptions) {
+		options.dryRun = &dryRun
+	}
+}
+
+// Deletes the account even if ClientConfig.DeletionPolicy protects it
+func OverrideDeletionPolicy() CallOption {
+	return func(options *callOptions) {
+		options.overridePolicy = true
+	}
+}
+
+func resolveOptions(opts []CallOption) callOptions {
+	var options callOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// Applies the options to the context of the call, the returned cancel function has to be called once the call is finished
+func applyOptions(ctx context.Context, operation string, opts []CallOption) (context.Context, context.CancelFunc) {
+	options := resolveOptions(opts)
+
+	ctx = http.WithOperation(ctx, operation)
+	if options.retries != nil {
+		ctx = http.WithRetries(ctx, options.retries)
+	}
+	if options.retryPolicy != nil {
+		ctx = http.WithRetryPolicy(ctx, options.retryPolicy)
+	}
+	if len(options.headers) > 0 {
+		ctx = http.WithHeaders(ctx, options.headers)
+	}
+	if len(options.idempotencyKey) > 0 {
+		ctx = http.WithIdempotencyKey(ctx, options.idempotencyKey)
+	}
+	if options.responseInfo != nil {
+		ctx = http.WithResponseInfo(ctx, options.responseInfo)
+	}
+	if options.dryRun != nil {
+		ctx = http.WithDryRun(ctx, *options.dryRun)
+	}
+	if options.timeout > 0 {
+		return context.WithTimeout(ctx, options.timeout)
+	}
+	return ctx, func() {}
+}
@@ -0,0 +1,103 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"accountapi-client/account/accounttest"
+	"accountapi-client/http"
+	"accountapi-client/retry"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFetchWithTimeoutOption(t *testing.T) {
+	t.Logf("Given fake server failing all the requests")
+	server := accountfake.NewServer()
+	defer server.Close()
+	server.DropResponses(503, 10)
+
+	t.Logf("And given account client with long retry delays")
+	client, _ := account.NewClient(account.ClientConfig{
+		Timeout:       time.Second,
+		Url:           server.Url(),
+		RetriesConfig: &retry.RetriesConfig{MaxRetries: 3, Delay: time.Second, Factor: 2},
+	})
+
+	t.Logf("When fetching an account with a timeout shorter than the delays")
+	startTime := time.Now()
+	_, err := client.Fetch(context.Background(), &account.FetchAccountRequest{Id: "ad27b0e5-8e82-4a4d-a0c1-2ae8ab7a8b4e"},
+		account.WithTimeout(50*time.Millisecond))
+
+	t.Logf("Should stop during the first delay and return the last error")
+	var httpError *http.ClientHttpError
+	assert.True(t, errors.As(err, &httpError), fmt.Sprint(err))
+	assert.Equal(t, 1, len(server.Requests()))
+	assert.True(t, time.Now().Sub(startTime) < time.Second)
+}
+
+func TestCreateWithCallOptions(t *testing.T) {
+	t.Logf("Given fake server losing the first response")
+	server := accountfake.NewServer()
+	defer server.Close()
+	server.DropResponses(503, 1)
+
+	t.Logf("And given account client")
+	client := server.NewClient()
+
+	t.Logf("When creating account without retries")
+	request := accounttest.NewCreateAccountRequest()
+	var info http.ResponseInfo
+	response, err := client.Create(context.Background(), request,
+		account.NoRetry(),
+		account.WithHeader("X-Tenant", "eu"),
+		account.WithIdempotencyKey("f0b3c2a1"),
+		account.WithResponseInfo(&info))
+
+	t.Logf("Should send a single request with the header and key")
+	var httpError *http.ClientHttpError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Nil(t, response)
+	assert.Equal(t, 1, info.Attempts)
+	requests := server.Requests()
+	assert.Equal(t, 1, len(requests))
+	assert.Equal(t, "eu", requests[0].Header.Get("X-Tenant"))
+	assert.Equal(t, "f0b3c2a1", requests[0].Header.Get("Idempotency-Key"))
+
+	t.Logf("When retrying it with the same key and custom retries")
+	server.DropResponses(503, 2)
+	response, err = client.Create(context.Background(), request,
+		account.WithRetries(retry.RetriesConfig{MaxRetries: 2, Delay: time.Millisecond, Factor: 1}),
+		account.WithIdempotencyKey("f0b3c2a1"))
+
+	t.Logf("Should create the account once")
+	assert.NoError(t, err)
+	assert.Equal(t, request.Account.Id, response.Account.Id)
+	assert.Equal(t, 4, len(server.Requests()))
+	assert.Equal(t, 1, len(server.Accounts()))
+}
+
+func TestFetchWithTimeout(t *testing.T) {
+	t.Logf("Given fake server with an account")
+	server := accountfake.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	created, _ := client.Create(context.Background(), accounttest.NewCreateAccountRequest())
+
+	t.Logf("When fetching it with already exceeded timeout")
+	response, err := client.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Account.Id}, account.WithTimeout(time.Nanosecond))
+
+	t.Logf("Should return an error")
+	assert.Error(t, err)
+	assert.Nil(t, response)
+
+	t.Logf("When fetching it with invalid retries")
+	response, err = client.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Account.Id}, account.WithRetries(retry.RetriesConfig{MaxRetries: -1}))
+
+	t.Logf("Should return retries config error")
+	assert.Equal(t, retry.MaxRetriesNegativeError, err)
+	assert.Nil(t, response)
+}
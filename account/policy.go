@@ -0,0 +1,40 @@
+package account
+
+// Decides whether the fetched account can be deleted, a non-nil error blocks the deletion and is returned by Delete
+type DeletionPolicy func(account *Account) error
+
+// Rules of ProtectAccounts, an account matching any of them is protected
+type ProtectionRules struct {
+	// Values of Attributes.Status, e.g. `confirmed`
+	Statuses    []string
+	CustomerIds []string
+	Countries   []string
+}
+
+// Creates DeletionPolicy which blocks deletion of accounts matching the rules with ProtectedAccountError
+func ProtectAccounts(rules ProtectionRules) DeletionPolicy {
+	return func(account *Account) error {
+		if account.Attributes == nil {
+			return nil
+		}
+		if contains(rules.Statuses, account.Attributes.Status) {
+			return &ProtectedAccountError{AccountId: account.Id, Rule: "status " + account.Attributes.Status}
+		}
+		if contains(rules.CustomerIds, account.Attributes.CustomerId) {
+			return &ProtectedAccountError{AccountId: account.Id, Rule: "customer id " + account.Attributes.CustomerId}
+		}
+		if contains(rules.Countries, account.Attributes.Country) {
+			return &ProtectedAccountError{AccountId: account.Id, Rule: "country " + account.Attributes.Country}
+		}
+		return nil
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if len(value) > 0 && candidate == value {
+			return true
+		}
+	}
+	return false
+}
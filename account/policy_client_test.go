@@ -0,0 +1,124 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"accountapi-client/account/accounttest"
+	"accountapi-client/retry"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	corehttp "net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestMutationsOfReadOnlyClient(t *testing.T) {
+	t.Logf("Given fake server with an account")
+	server := accountfake.NewServer()
+	defer server.Close()
+	created, _ := server.NewClient().Create(context.Background(), accounttest.NewCreateAccountRequest())
+
+	t.Logf("And given read-only account client")
+	client := server.NewClient()
+	client.ReadOnly = true
+
+	t.Logf("When creating and deleting accounts")
+	_, createErr := client.Create(context.Background(), accounttest.NewCreateAccountRequest())
+	deleteErr := client.Delete(context.Background(), &account.DeleteAccountRequest{Id: created.Account.Id, Version: 0})
+
+	t.Logf("Should refuse them without calling the server")
+	assert.Equal(t, &account.ReadOnlyError{Operation: account.CreateOperation}, createErr)
+	assert.Equal(t, &account.ReadOnlyError{Operation: account.DeleteOperation}, deleteErr)
+	assert.Equal(t, 1, len(server.Requests()))
+
+	t.Logf("When fetching the account")
+	_, err := client.Fetch(context.Background(), &account.FetchAccountRequest{Id: created.Account.Id})
+
+	t.Logf("Should return it")
+	assert.NoError(t, err)
+}
+
+func TestDeleteWithDeletionPolicy(t *testing.T) {
+	t.Logf("Given fake server with confirmed and pending accounts")
+	server := accountfake.NewServer()
+	defer server.Close()
+	confirmedRequest, pendingRequest := accounttest.NewCreateAccountRequest(), accounttest.NewCreateAccountRequest()
+	confirmedRequest.Account.Attributes.Status = "confirmed"
+	pendingRequest.Account.Attributes.Status = "pending"
+	confirmed, _ := server.NewClient().Create(context.Background(), confirmedRequest)
+	pending, _ := server.NewClient().Create(context.Background(), pendingRequest)
+
+	t.Logf("And given account client protecting confirmed accounts")
+	client, _ := account.NewClient(account.ClientConfig{
+		Timeout:        time.Second,
+		Url:            server.Url(),
+		RetriesConfig:  &retry.RetriesConfig{MaxRetries: 3, Delay: time.Millisecond, Factor: 2},
+		DeletionPolicy: account.ProtectAccounts(account.ProtectionRules{Statuses: []string{"confirmed"}}),
+	})
+
+	t.Logf("When deleting both accounts")
+	confirmedErr := client.Delete(context.Background(), &account.DeleteAccountRequest{Id: confirmed.Account.Id})
+	pendingErr := client.Delete(context.Background(), &account.DeleteAccountRequest{Id: pending.Account.Id})
+
+	t.Logf("Should delete only the pending one")
+	var protectedError *account.ProtectedAccountError
+	assert.True(t, errors.As(confirmedErr, &protectedError))
+	assert.Equal(t, confirmed.Account.Id, protectedError.AccountId)
+	assert.NoError(t, pendingErr)
+	assert.Equal(t, []string{confirmed.Account.Id}, accountIds(server.Accounts()))
+
+	t.Logf("When deleting the confirmed one in dry run")
+	err := client.Delete(context.Background(), &account.DeleteAccountRequest{Id: confirmed.Account.Id}, account.DryRun(true))
+
+	t.Logf("Should still be blocked by the policy")
+	assert.True(t, errors.As(err, &protectedError))
+
+	t.Logf("When deleting it with the policy overridden")
+	err = client.Delete(context.Background(), &account.DeleteAccountRequest{Id: confirmed.Account.Id}, account.OverrideDeletionPolicy())
+
+	t.Logf("Should delete it")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(server.Accounts()))
+}
+
+func TestDeleteWithDeletionPolicyAndTimeout(t *testing.T) {
+	t.Logf("Given fake server with an account behind a slow proxy")
+	server := accountfake.NewServer()
+	defer server.Close()
+	created, _ := server.NewClient().Create(context.Background(), accounttest.NewCreateAccountRequest())
+	proxy := httputil.NewSingleHostReverseProxy(server.Url())
+	slowServer := httptest.NewServer(corehttp.HandlerFunc(func(response corehttp.ResponseWriter, request *corehttp.Request) {
+		time.Sleep(50 * time.Millisecond)
+		proxy.ServeHTTP(response, request)
+	}))
+	defer slowServer.Close()
+	slowUrl, _ := url.Parse(slowServer.URL)
+
+	t.Logf("And given account client with a deletion policy")
+	client, _ := account.NewClient(account.ClientConfig{
+		Timeout:        time.Second,
+		Url:            slowUrl,
+		RetriesConfig:  &retry.RetriesConfig{MaxRetries: 0},
+		DeletionPolicy: account.ProtectAccounts(account.ProtectionRules{Statuses: []string{"confirmed"}}),
+	})
+
+	t.Logf("When deleting the account with a timeout enough for one request but not for two")
+	err := client.Delete(context.Background(), &account.DeleteAccountRequest{Id: created.Account.Id}, account.WithTimeout(80*time.Millisecond))
+
+	t.Logf("Should run the policy check and the delete within the same timeout")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), fmt.Sprint(err))
+	assert.Equal(t, 1, len(server.Accounts()))
+}
+
+func accountIds(accounts []account.Account) []string {
+	ids := make([]string, 0, len(accounts))
+	for _, found := range accounts {
+		ids = append(ids, found.Id)
+	}
+	return ids
+}
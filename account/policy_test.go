@@ -0,0 +1,27 @@
+package account
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProtectAccounts(t *testing.T) {
+	t.Logf("Given policy protecting confirmed accounts, a customer and a country")
+	policy := ProtectAccounts(ProtectionRules{
+		Statuses:    []string{"confirmed"},
+		CustomerIds: []string{"customer-1"},
+		Countries:   []string{"GB"},
+	})
+
+	t.Logf("When checking accounts")
+	confirmed := policy(&Account{Id: "1", Attributes: &Attributes{Country: "PL", Status: "confirmed"}})
+	customer := policy(&Account{Id: "2", Attributes: &Attributes{Country: "PL", CustomerId: "customer-1"}})
+	country := policy(&Account{Id: "3", Attributes: &Attributes{Country: "GB"}})
+	other := policy(&Account{Id: "4", Attributes: &Attributes{Country: "PL", Status: "pending"}})
+
+	t.Logf("Should block only matching ones")
+	assert.Equal(t, &ProtectedAccountError{AccountId: "1", Rule: "status confirmed"}, confirmed)
+	assert.Equal(t, &ProtectedAccountError{AccountId: "2", Rule: "customer id customer-1"}, customer)
+	assert.Equal(t, &ProtectedAccountError{AccountId: "3", Rule: "country GB"}, country)
+	assert.NoError(t, other)
+}
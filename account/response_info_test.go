@@ -0,0 +1,30 @@
+package account_test
+
+import (
+	"accountapi-client/account"
+	"accountapi-client/account/accountfake"
+	"accountapi-client/account/accounttest"
+	"accountapi-client/http"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFetchWithResponseInfo(t *testing.T) {
+	t.Logf("Given fake server with an account")
+	server := accountfake.NewServer()
+	defer server.Close()
+	client := server.NewClient()
+	created, _ := client.Create(context.Background(), accounttest.NewCreateAccountRequest())
+
+	t.Logf("When fetching it with ResponseInfo")
+	var info http.ResponseInfo
+	ctx := http.WithResponseInfo(context.Background(), &info)
+	_, err := client.Fetch(ctx, &account.FetchAccountRequest{Id: created.Account.Id})
+
+	t.Logf("Should expose the server's request id")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, info.StatusCode)
+	assert.Equal(t, "2", info.RequestId)
+	assert.Equal(t, 1, info.Attempts)
+}
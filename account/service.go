@@ -0,0 +1,15 @@
+package account
+
+import "context"
+
+// Operations of accountapi, implemented by Client. Consumers should depend on it instead of *Client,
+// so it can be replaced by accountmock.Mock or other implementations in tests.
+type AccountService interface {
+	Create(ctx context.Context, request *CreateAccountRequest, opts ...CallOption) (*CreateAccountResponse, error)
+	Fetch(ctx context.Context, request *FetchAccountRequest, opts ...CallOption) (*FetchAccountResponse, error)
+	List(ctx context.Context, request *ListAccountsRequest, opts ...CallOption) (*ListAccountResponse, error)
+	Delete(ctx context.Context, request *DeleteAccountRequest, opts ...CallOption) error
+	Health(ctx context.Context, opts ...CallOption) (*Health, error)
+}
+
+var _ AccountService = (*Client)(nil)
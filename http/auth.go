@@ -0,0 +1,36 @@
+package http
+
+import (
+	"context"
+	corehttp "net/http"
+)
+
+// Authorises outgoing requests, it is called by the Client before every attempt (including retries)
+// so credentials can be rotated in-between them.
+type Authenticator interface {
+	Authenticate(ctx context.Context, request *corehttp.Request) error
+}
+
+// Authenticator which caches credentials and is able to drop them.
+//
+// Whenever the server responds with HTTP-401 the Client calls Invalidate with the rejected request
+// and then repeats that attempt once with fresh credentials.
+type RefreshableAuthenticator interface {
+	Authenticator
+	Invalidate(request *corehttp.Request)
+}
+
+// Adapter that allows to use an ordinary function as an Authenticator
+type AuthenticatorFunc func(ctx context.Context, request *corehttp.Request) error
+
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, request *corehttp.Request) error {
+	return f(ctx, request)
+}
+
+// Creates an Authenticator which sets a static bearer token on every request
+func BearerToken(token string) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, request *corehttp.Request) error {
+		request.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
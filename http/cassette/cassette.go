@@ -0,0 +1,272 @@
+// Provides a transport recording http traffic to JSON files (cassettes) and replaying it offline,
+// which makes tests of clients built on http.Client deterministic and independent of running services.
+//
+// The Cassette is plugged in as http.ClientConfig.Transport:
+//
+//	recorder, err := cassette.New(cassette.Config{Path: "testdata/fetch.json", Mode: cassette.ModeAuto})
+//	client, err := http.NewClient(http.ClientConfig{Timeout: time.Second, Transport: recorder})
+//	...
+//	err = recorder.Save()
+//
+// In ModeRecord requests are sent with Config.Transport and interactions are kept until Save writes them,
+// in ModeReplay responses are served from the file and requests which don't match any interaction fail
+// with NoInteractionError. ModeAuto replays the file if it exists and records it otherwise.
+//
+// Requests are matched with Config.Matcher (DefaultMatcher compares method, path, query and body),
+// headers listed in Config.ScrubHeaders are replaced with logging.Redacted before being recorded.
+package cassette
+
+import (
+	"accountapi-client/logging"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	corehttp "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type Mode int
+
+const (
+	ModeAuto Mode = iota
+	ModeRecord
+	ModeReplay
+)
+
+// Headers scrubbed when Config.ScrubHeaders is nil
+var DefaultScrubHeaders = []string{"Authorization", "Signature", "Cookie", "Set-Cookie", "X-Api-Key", "Proxy-Authorization"}
+
+// Returned by New when Config.Path is empty
+var PathEmptyError = errors.New("path cannot be empty")
+
+// Returned by the Cassette in ModeReplay when no unused interaction matches the request
+type NoInteractionError struct {
+	Method string
+	Url    string
+}
+
+func (e *NoInteractionError) Error() string {
+	return fmt.Sprintf("cassette has no interaction matching %s %s", e.Method, e.Url)
+}
+
+type Config struct {
+	// JSON file the interactions are recorded to and replayed from
+	Path string
+	Mode Mode
+	// Defaults to DefaultMatcher
+	Matcher Matcher
+	// Defaults to DefaultScrubHeaders, an empty slice disables scrubbing
+	ScrubHeaders []string
+	// Sends requests in ModeRecord, defaults to net/http.DefaultTransport
+	Transport corehttp.RoundTripper
+}
+
+// Recorded request and response
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method  string              `json:"method"`
+	Url     string              `json:"url"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    string              `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int                 `json:"status_code"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       string              `json:"body,omitempty"`
+}
+
+// Transport recording or replaying interactions, see package docs
+type Cassette struct {
+	config       Config
+	mode         Mode
+	mutex        sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Creates new instance of Cassette, in ModeReplay (or ModeAuto with an existing file) interactions are read from
+// Config.Path. If the path is empty it returns PathEmptyError, errors of reading and parsing the file are returned as well.
+func New(config Config) (*Cassette, error) {
+	if len(config.Path) == 0 {
+		return nil, PathEmptyError
+	}
+	if config.Matcher == nil {
+		config.Matcher = DefaultMatcher
+	}
+	if config.ScrubHeaders == nil {
+		config.ScrubHeaders = DefaultScrubHeaders
+	}
+	if config.Transport == nil {
+		config.Transport = corehttp.DefaultTransport
+	}
+
+	cassette := &Cassette{config: config, mode: config.Mode}
+	if cassette.mode == ModeAuto {
+		cassette.mode = ModeRecord
+		if _, err := os.Stat(config.Path); err == nil {
+			cassette.mode = ModeReplay
+		}
+	}
+	if cassette.mode == ModeReplay {
+		data, err := ioutil.ReadFile(config.Path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &cassette.interactions); err != nil {
+			return nil, err
+		}
+		cassette.used = make([]bool, len(cassette.interactions))
+	}
+	return cassette, nil
+}
+
+// Returns ModeRecord or ModeReplay, ModeAuto is resolved by New
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Returns copies of recorded or loaded interactions
+func (c *Cassette) Interactions() []Interaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Interaction{}, c.interactions...)
+}
+
+func (c *Cassette) RoundTrip(request *corehttp.Request) (*corehttp.Response, error) {
+	body, err := readBody(request)
+	if err != nil {
+		return nil, err
+	}
+	if c.mode == ModeReplay {
+		return c.replay(request, body)
+	}
+	return c.record(request, body)
+}
+
+// Writes recorded interactions to Config.Path, it does nothing in ModeReplay
+func (c *Cassette) Save() error {
+	if c.mode == ModeReplay {
+		return nil
+	}
+	c.mutex.Lock()
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.config.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.config.Path, data, 0644)
+}
+
+func (c *Cassette) record(request *corehttp.Request, body []byte) (*corehttp.Response, error) {
+	sent := request.Clone(request.Context())
+	if body != nil {
+		sent.Body = ioutil.NopCloser(bytes.NewReader(body))
+		sent.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	response, err := c.config.Transport.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  request.Method,
+			Url:     request.URL.String(),
+			Headers: c.scrub(request.Header),
+			Body:    string(body),
+		},
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Headers:    c.scrub(response.Header),
+			Body:       string(responseBody),
+		},
+	}
+	c.mutex.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mutex.Unlock()
+	return response, nil
+}
+
+// Serves the first unused interaction matching the request
+func (c *Cassette) replay(request *corehttp.Request, body []byte) (*corehttp.Response, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, interaction := range c.interactions {
+		if c.used[i] || !c.config.Matcher(request, body, interaction.Request) {
+			continue
+		}
+		c.used[i] = true
+
+		header := corehttp.Header{}
+		for key, values := range interaction.Response.Headers {
+			header[key] = append([]string{}, values...)
+		}
+		return &corehttp.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, corehttp.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}, nil
+	}
+	return nil, &NoInteractionError{Method: request.Method, Url: request.URL.String()}
+}
+
+func (c *Cassette) scrub(header corehttp.Header) map[string][]string {
+	scrubbed := make(map[string][]string, len(header))
+	for key, values := range header {
+		scrubbed[key] = append([]string{}, values...)
+	}
+	for _, key := range c.config.ScrubHeaders {
+		key = corehttp.CanonicalHeaderKey(key)
+		if values, ok := scrubbed[key]; ok {
+			for i := range values {
+				values[i] = logging.Redacted
+			}
+		}
+	}
+	return scrubbed
+}
+
+// Reads and closes the body, the request itself is not modified as RoundTripper must not do it
+func readBody(request *corehttp.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == corehttp.NoBody {
+		return nil, nil
+	}
+	defer request.Body.Close()
+	return ioutil.ReadAll(request.Body)
+}
+
+func parseUrl(rawUrl string) *url.URL {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return &url.URL{}
+	}
+	return parsed
+}
@@ -0,0 +1,151 @@
+package cassette
+
+import (
+	"accountapi-client/http"
+	"accountapi-client/logging"
+	"accountapi-client/retry"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	corehttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dummy struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	t.Logf("Given HTTP server echoing request bodies")
+	server := httptest.NewServer(corehttp.HandlerFunc(func(res corehttp.ResponseWriter, req *corehttp.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		res.Header().Set("Set-Cookie", "session=secret")
+		res.Write(body)
+	}))
+	serverUrl, _ := url.Parse(server.URL + "/dummies?page=1&size=2")
+
+	t.Logf("And given Client recording to a cassette")
+	path := filepath.Join(t.TempDir(), "cassettes", "dummies.json")
+	recorder, _ := New(Config{Path: path})
+	assert.Equal(t, ModeRecord, recorder.Mode())
+	client := newClient(recorder)
+
+	t.Logf("When calling POST and saving the cassette")
+	var recorded dummy
+	err := client.Post(context.Background(), serverUrl, dummy{Id: 1, Title: "Jan"}, &recorded)
+	assert.NoError(t, err)
+	assert.NoError(t, recorder.Save())
+	server.Close()
+
+	t.Logf("Should scrub secret headers in the file")
+	data, _ := ioutil.ReadFile(path)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "Bearer")
+	assert.Contains(t, string(data), logging.Redacted)
+
+	t.Logf("When replaying the same call against another host with reordered query")
+	player, _ := New(Config{Path: path})
+	assert.Equal(t, ModeReplay, player.Mode())
+	client = newClient(player)
+	var replayed dummy
+	err = client.Post(context.Background(), createUrl("http://accountapi.internal/dummies?size=2&page=1"), dummy{Id: 1, Title: "Jan"}, &replayed)
+
+	t.Logf("Should return the recorded response")
+	assert.NoError(t, err)
+	assert.Equal(t, recorded, replayed)
+
+	t.Logf("When replaying a call with a different body")
+	err = client.Post(context.Background(), serverUrl, dummy{Id: 2, Title: "Jan"}, &replayed)
+
+	t.Logf("Should return NoInteractionError")
+	var noInteractionError *NoInteractionError
+	assert.True(t, errors.As(err, &noInteractionError))
+}
+
+func TestCassetteReplayInOrder(t *testing.T) {
+	t.Logf("Given cassette with a failed and a successful interaction of the same call")
+	path := filepath.Join(t.TempDir(), "retry.json")
+	ioutil.WriteFile(path, []byte(`[
+		{"request": {"method": "GET", "url": "http://localhost/dummies/1"}, "response": {"status_code": 503}},
+		{"request": {"method": "GET", "url": "http://localhost/dummies/1"}, "response": {"status_code": 200, "body": "{\"id\": 1, \"title\": \"Jan\"}"}}
+	]`), 0644)
+
+	t.Logf("And given Client replaying it with method and path matchers only")
+	player, err := New(Config{Path: path, Mode: ModeReplay, Matcher: All(MatchMethod, MatchPath)})
+	assert.NoError(t, err)
+	client := newClient(player)
+
+	t.Logf("When calling GET")
+	var response dummy
+	err = client.Get(context.Background(), createUrl("http://localhost/dummies/1?ignored=true"), &response)
+
+	t.Logf("Should replay interactions in order as retries")
+	assert.NoError(t, err)
+	assert.Equal(t, dummy{Id: 1, Title: "Jan"}, response)
+}
+
+func TestNewWithInvalidConfig(t *testing.T) {
+	t.Logf("Given Config without path")
+
+	t.Logf("When creating Cassette")
+	cassette, err := New(Config{})
+
+	t.Logf("Should return '%s' error", PathEmptyError)
+	assert.Equal(t, PathEmptyError, err)
+	assert.Nil(t, cassette)
+
+	t.Logf("When replaying a missing file")
+	cassette, err = New(Config{Path: filepath.Join(t.TempDir(), "missing.json"), Mode: ModeReplay})
+
+	t.Logf("Should return an error")
+	assert.Error(t, err)
+	assert.Nil(t, cassette)
+}
+
+func TestCassetteDoesNotModifyRequest(t *testing.T) {
+	t.Logf("Given HTTP server echoing request bodies")
+	server := httptest.NewServer(corehttp.HandlerFunc(func(res corehttp.ResponseWriter, req *corehttp.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		res.Write(body)
+	}))
+	defer server.Close()
+
+	t.Logf("And given cassette recording")
+	recorder, _ := New(Config{Path: filepath.Join(t.TempDir(), "dummies.json")})
+
+	t.Logf("When sending a request with a body through it")
+	request, _ := corehttp.NewRequest("POST", server.URL, strings.NewReader(`{"id":1}`))
+	body := request.Body
+	response, err := recorder.RoundTrip(request)
+
+	t.Logf("Should send the body without replacing it on the request")
+	assert.NoError(t, err)
+	responseBody, _ := ioutil.ReadAll(response.Body)
+	assert.Equal(t, `{"id":1}`, string(responseBody))
+	assert.True(t, body == request.Body)
+	assert.Equal(t, `{"id":1}`, recorder.Interactions()[0].Request.Body)
+}
+
+func newClient(transport corehttp.RoundTripper) *http.Client {
+	client, _ := http.NewClient(http.ClientConfig{
+		Timeout:   time.Second,
+		Retries:   &retry.RetriesConfig{MaxRetries: 3, Delay: time.Millisecond, Factor: 2},
+		Transport: transport,
+		Auth:      http.BearerToken("token"),
+		Headers:   http.Headers{"Content-Type": "application/json"},
+	})
+	return client
+}
+
+func createUrl(value string) *url.URL {
+	result, _ := url.Parse(value)
+	return result
+}
+
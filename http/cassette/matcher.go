@@ -0,0 +1,59 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	corehttp "net/http"
+	"reflect"
+)
+
+// Decides whether the request (with its body already read) matches the recorded one
+type Matcher func(request *corehttp.Request, body []byte, recorded Request) bool
+
+// Matches method, path, query and body
+var DefaultMatcher = All(MatchMethod, MatchPath, MatchQuery, MatchBody)
+
+// Matches when all the matchers match
+func All(matchers ...Matcher) Matcher {
+	return func(request *corehttp.Request, body []byte, recorded Request) bool {
+		for _, matcher := range matchers {
+			if !matcher(request, body, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func MatchMethod(request *corehttp.Request, body []byte, recorded Request) bool {
+	return request.Method == recorded.Method
+}
+
+// Compares paths ignoring scheme and host, so cassettes recorded against one host can be replayed against another
+func MatchPath(request *corehttp.Request, body []byte, recorded Request) bool {
+	return request.URL.Path == parseUrl(recorded.Url).Path
+}
+
+// Compares query parameters regardless of their order
+func MatchQuery(request *corehttp.Request, body []byte, recorded Request) bool {
+	return reflect.DeepEqual(request.URL.Query(), parseUrl(recorded.Url).Query())
+}
+
+// Compares JSON bodies semantically and other bodies byte by byte
+func MatchBody(request *corehttp.Request, body []byte, recorded Request) bool {
+	if bytes.Equal(body, []byte(recorded.Body)) {
+		return true
+	}
+	var actual, expected interface{}
+	if json.Unmarshal(body, &actual) != nil || json.Unmarshal([]byte(recorded.Body), &expected) != nil {
+		return false
+	}
+	return reflect.DeepEqual(actual, expected)
+}
+
+// Creates a Matcher comparing values of the header, scrubbed headers can't be matched
+func MatchHeader(name string) Matcher {
+	return func(request *corehttp.Request, body []byte, recorded Request) bool {
+		return reflect.DeepEqual(request.Header.Values(name), corehttp.Header(recorded.Headers).Values(name))
+	}
+}
@@ -0,0 +1,216 @@
+// Provides a fault-injection transport for chaos testing of resilience settings (retries, timeouts, failover etc.).
+//
+// The Transport is plugged in as http.ClientConfig.Transport, or with Middleware as one of http.ClientConfig.Middlewares,
+// and injects Fault into requests according to Config:
+//
+//	transport := chaos.New(chaos.Config{
+//		// the first two requests fail, the following ones are served by the real transport
+//		Script: []chaos.Fault{{Reset: true}, {StatusCode: 503}},
+//		// then 10% of requests get 200ms-300ms of extra latency
+//		Rules: []chaos.Rule{{Rate: 0.1, Fault: chaos.Fault{Latency: 200 * time.Millisecond, Jitter: 100 * time.Millisecond}}},
+//	})
+//	client, err := http.NewClient(http.ClientConfig{Timeout: time.Second, Transport: transport})
+package chaos
+
+import (
+	"accountapi-client/http"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	corehttp "net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Describes what happens to a request, the zero value passes it through untouched.
+//
+// Latency (with Jitter) is applied first, then the first of Reset, Timeout and StatusCode which is set decides
+// the outcome instead of the real transport, PartialBody and MalformedJSON then spoil the response body.
+type Fault struct {
+	Latency time.Duration
+	// Uniformly distributed latency added on top of Latency
+	Jitter time.Duration
+	// Fails with connection reset by peer
+	Reset bool
+	// Hangs for the duration (or until the request is cancelled) and fails with a timeout error
+	Timeout time.Duration
+	// Responds with the status code and Body without calling the real transport
+	StatusCode int
+	Body       string
+	// Cuts the response body in half and fails reading it with io.ErrUnexpectedEOF
+	PartialBody bool
+	// Replaces the response body with truncated JSON
+	MalformedJSON bool
+}
+
+// Fault injected into a share of requests
+type Rule struct {
+	// Probability between 0 and 1
+	Rate  float64
+	Fault Fault
+}
+
+type Config struct {
+	// Faults injected into consecutive requests, one per request, before Rules are applied
+	Script []Fault
+	// Evaluated in order for every request after the Script, the first rule which fires is applied
+	Rules []Rule
+	// Real transport, defaults to net/http.DefaultTransport
+	Transport corehttp.RoundTripper
+	// Seed of the random generator used by Rules and Jitter, zero seeds it with the current time
+	Seed int64
+}
+
+// Transport injecting faults, see package docs
+type Transport struct {
+	config   Config
+	mutex    sync.Mutex
+	random   *rand.Rand
+	requests int
+}
+
+// Creates new instance of Transport
+func New(config Config) *Transport {
+	if config.Transport == nil {
+		config.Transport = corehttp.DefaultTransport
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Transport{config: config, random: rand.New(rand.NewSource(seed))}
+}
+
+// Creates http.Middleware injecting faults into requests passed to the next round tripper, Config.Transport is ignored
+func Middleware(config Config) http.Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		config.Transport = next
+		return New(config)
+	}
+}
+
+// Returns number of requests handled so far
+func (t *Transport) Requests() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.requests
+}
+
+func (t *Transport) RoundTrip(request *corehttp.Request) (*corehttp.Response, error) {
+	fault, latency := t.next()
+
+	if latency > 0 {
+		if err := sleep(request.Context(), latency); err != nil {
+			return nil, err
+		}
+	}
+
+	var response *corehttp.Response
+	var err error
+	switch {
+	case fault.Reset:
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	case fault.Timeout > 0:
+		if err := sleep(request.Context(), fault.Timeout); err != nil {
+			return nil, err
+		}
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}
+	case fault.StatusCode > 0:
+		response = respond(request, fault.StatusCode, fault.Body)
+	default:
+		response, err = t.config.Transport.RoundTrip(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if fault.MalformedJSON {
+		response.Body.Close()
+		setBody(response, []byte(`{"data": {"id": "ad27b0e5`))
+	}
+	if fault.PartialBody {
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		response.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body[:len(body)/2]), errorReader{io.ErrUnexpectedEOF}))
+		response.ContentLength = -1
+	}
+	return response, nil
+}
+
+// Picks the fault of the next request along with its latency
+func (t *Transport) next() (Fault, time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.requests++
+
+	var fault Fault
+	if t.requests <= len(t.config.Script) {
+		fault = t.config.Script[t.requests-1]
+	} else {
+		for _, rule := range t.config.Rules {
+			if t.random.Float64() < rule.Rate {
+				fault = rule.Fault
+				break
+			}
+		}
+	}
+
+	latency := fault.Latency
+	if fault.Jitter > 0 {
+		latency += time.Duration(t.random.Int63n(int64(fault.Jitter)))
+	}
+	return fault, latency
+}
+
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func respond(request *corehttp.Request, statusCode int, body string) *corehttp.Response {
+	response := &corehttp.Response{
+		Status:     strconv.Itoa(statusCode) + " " + corehttp.StatusText(statusCode),
+		StatusCode: statusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     corehttp.Header{},
+		Request:    request,
+	}
+	setBody(response, []byte(body))
+	return response
+}
+
+func setBody(response *corehttp.Response, body []byte) {
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+}
+
+type errorReader struct {
+	err error
+}
+
+func (e errorReader) Read(p []byte) (int, error) {
+	return 0, e.err
+}
+
+// Implements net.Error
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
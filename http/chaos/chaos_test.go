@@ -0,0 +1,152 @@
+package chaos
+
+import (
+	"accountapi-client/http"
+	"accountapi-client/retry"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	corehttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type dummy struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestTransportWithScript(t *testing.T) {
+	t.Logf("Given HTTP server")
+	server := httptest.NewServer(corehttp.HandlerFunc(func(res corehttp.ResponseWriter, req *corehttp.Request) {
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with transport resetting the first connection and failing the second request")
+	transport := New(Config{Script: []Fault{{Reset: true}, {StatusCode: 503}}})
+	client := newClient(transport, time.Second)
+
+	t.Logf("When calling GET")
+	var response dummy
+	var info http.ResponseInfo
+	err := client.Get(http.WithResponseInfo(context.Background(), &info), serverUrl, &response)
+
+	t.Logf("Should succeed on the third attempt")
+	assert.NoError(t, err)
+	assert.Equal(t, dummy{Id: 1, Title: "Jan"}, response)
+	assert.Equal(t, 3, info.Attempts)
+	assert.Equal(t, 3, transport.Requests())
+}
+
+func TestTransportWithFaults(t *testing.T) {
+	t.Logf("Given HTTP server")
+	server := httptest.NewServer(corehttp.HandlerFunc(func(res corehttp.ResponseWriter, req *corehttp.Request) {
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	testCases := []struct {
+		Name               string
+		Fault              Fault
+		ExpectedErrorClass http.ErrorClass
+		ExpectedStatusCode int
+	}{
+		{Name: "reset", Fault: Fault{Reset: true}, ExpectedErrorClass: http.NetworkErrorClass},
+		{Name: "timeout", Fault: Fault{Timeout: time.Millisecond}, ExpectedErrorClass: http.NetworkErrorClass},
+		{Name: "client timeout", Fault: Fault{Timeout: time.Minute}, ExpectedErrorClass: http.NetworkErrorClass},
+		{Name: "partial body", Fault: Fault{PartialBody: true}, ExpectedErrorClass: http.IoErrorClass},
+		{Name: "malformed json", Fault: Fault{MalformedJSON: true}, ExpectedErrorClass: http.ParsingErrorClass},
+		{Name: "status code", Fault: Fault{StatusCode: 429, Body: `{"error_message": "slow down"}`}, ExpectedStatusCode: 429},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given Client with transport always injecting %s", testCase.Name)
+		transport := New(Config{Rules: []Rule{{Rate: 1, Fault: testCase.Fault}}})
+		client := newClient(transport, 50*time.Millisecond)
+
+		t.Logf("When calling GET")
+		var response dummy
+		err := client.Get(context.Background(), serverUrl, &response)
+
+		var clientError *http.ClientError
+		var httpError *http.ClientHttpError
+		if testCase.ExpectedStatusCode > 0 {
+			t.Logf("Should return ClientHttpError with %d status", testCase.ExpectedStatusCode)
+			assert.True(t, errors.As(err, &httpError))
+			assert.Equal(t, testCase.ExpectedStatusCode, httpError.StatusCode)
+			assert.JSONEq(t, testCase.Fault.Body, string(httpError.ResponseBody))
+		} else {
+			t.Logf("Should return ClientError of %s class", testCase.ExpectedErrorClass)
+			assert.True(t, errors.As(err, &clientError))
+			assert.Equal(t, testCase.ExpectedErrorClass, clientError.Class)
+		}
+	}
+}
+
+func TestTransportWithRatesAndLatency(t *testing.T) {
+	t.Logf("Given HTTP server")
+	server := httptest.NewServer(corehttp.HandlerFunc(func(res corehttp.ResponseWriter, req *corehttp.Request) {}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given seeded transport failing half of the requests and delaying the others")
+	transport := New(Config{Seed: 42, Rules: []Rule{
+		{Rate: 0.5, Fault: Fault{StatusCode: 500}},
+		{Rate: 1, Fault: Fault{Latency: time.Millisecond, Jitter: time.Millisecond}},
+	}})
+
+	t.Logf("When sending 200 requests")
+	failures := 0
+	startTime := time.Now()
+	for i := 0; i < 200; i++ {
+		request, _ := corehttp.NewRequest("GET", serverUrl.String(), nil)
+		response, err := transport.RoundTrip(request)
+		assert.NoError(t, err)
+		if response.StatusCode == 500 {
+			failures++
+		}
+		response.Body.Close()
+	}
+
+	t.Logf("Should fail roughly half of them and delay the rest")
+	assert.InDelta(t, 100, failures, 30)
+	assert.True(t, time.Now().Sub(startTime) >= time.Duration(200-failures)*time.Millisecond)
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Logf("Given HTTP server")
+	calls := 0
+	server := httptest.NewServer(corehttp.HandlerFunc(func(res corehttp.ResponseWriter, req *corehttp.Request) {
+		calls++
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with chaos middleware failing the first attempt")
+	client, _ := http.NewClient(http.ClientConfig{
+		Timeout:     time.Second,
+		Retries:     &retry.RetriesConfig{MaxRetries: 3, Delay: time.Millisecond, Factor: 2},
+		Middlewares: []http.Middleware{Middleware(Config{Script: []Fault{{StatusCode: 502}}})},
+	})
+
+	t.Logf("When calling GET")
+	err := client.Get(context.Background(), serverUrl, nil)
+
+	t.Logf("Should reach the server only with the retry")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func newClient(transport corehttp.RoundTripper, timeout time.Duration) *http.Client {
+	client, _ := http.NewClient(http.ClientConfig{
+		Timeout:   timeout,
+		Retries:   &retry.RetriesConfig{MaxRetries: 3, Delay: time.Millisecond, Factor: 2},
+		Transport: transport,
+	})
+	return client
+}
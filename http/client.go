@@ -3,69 +3,259 @@
 // and by encapsulating all the errors that can happen along the way under once common format.
 //
 // The NewClient function creates a new instance of the Client by providing ClientConfig.
-// It is required to pass all the fields from that config.
+// Only Timeout is required, other fields are optional and fall back to defaults described by NewClient.
 //
-// For the time being it only provides GET, POST and DELETE operations.
+// It provides GET, POST, PUT, PATCH, DELETE and HEAD operations along with generic Do which takes a Request
+// and returns Response with metadata of the call.
 //
-// All calls support retries which can be defined in ClientConfig via retry.RetriesConfig, if you wish to disable them then set MaxRetries to 0
+// All calls support retries which can be defined in ClientConfig via retry.RetriesConfig (retry.DefaultRetriesConfig if it's nil),
+// if you wish to disable them then set MaxRetries to 0
+//
+// Requests can be authorised by providing an Authenticator in ClientConfig, e.g. OAuth2ClientCredentials
+// and signed by providing a Signer, e.g. HttpSignatureSigner
+//
+// Cross-cutting logic (correlation ids, metrics, chaos etc.) can be plugged in with Middleware,
+// either per attempt (ClientConfig.Middlewares) or per call wrapping retries (ClientConfig.CallMiddlewares)
 //
 package http
 
 import (
+	"accountapi-client/logging"
 	"accountapi-client/retry"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
-	"log"
 	corehttp "net/http"
 	"net/url"
 	"time"
 )
 
 type ClientConfig struct {
-	Timeout time.Duration
-	Retries *retry.RetriesConfig
-	Headers Headers
-	Logging bool
+	Timeout                time.Duration
+	CallTimeout            time.Duration
+	Transport              corehttp.RoundTripper
+	TransportConfig        *TransportConfig
+	TLS                    *TLSConfig
+	Failover               *FailoverConfig
+	Retries                *retry.RetriesConfig
+	RetryPolicy            RetryPolicy
+	DisableIdempotencyKeys bool
+	Headers                Headers
+	RequestIdHeader        string
+	Logging                bool
+	Logger                 logging.Logger
+	Metrics                Metrics
+	Tracer                 Tracer
+	Auth                   Authenticator
+	Signer                 Signer
+	Middlewares            []Middleware
+	CallMiddlewares        []Middleware
+	CoalesceRequests       bool
 }
 
 type Client struct {
-	client  *corehttp.Client
-	retry   *retry.Retry
-	headers Headers
-	logging bool
+	client          *corehttp.Client
+	retry           *retry.Retry
+	retryPolicy     RetryPolicy
+	callTimeout     time.Duration
+	requestIdHeader string
+	failover        *Failover
+	flights         *flightGroup
+	attempts        corehttp.RoundTripper
+	transport       corehttp.RoundTripper
 }
 
 type Headers map[string]string
 
+// Describes a call made with Client.Do
+type Request struct {
+	// Defaults to GET
+	Method string
+	Url    *url.URL
+	// Merged into query of the Url
+	Query url.Values
+	// Set on top of ClientConfig.Headers
+	Headers Headers
+	// Encoded with json.Marshal, no body is sent if it's nil
+	Body interface{}
+	// Pointer the response body will be written to by json.Unmarshal, the body is not decoded if it's nil
+	ResponseBody interface{}
+}
+
+// Result of Client.Do, Body holds the raw response body
+type Response struct {
+	ResponseInfo
+	Body []byte
+}
+
 // Creates a new instance of the Client.
 //
-// If ClientConfig.Timeout is zero or bellow it returns TimeoutZeroError.
+// If ClientConfig.Timeout is zero or bellow it returns TimeoutZeroError. Timeout limits every single attempt,
+// CallTimeout (if provided) limits the whole call including retries and delays between them.
+//
+// Requests are sent with Transport if it's provided, otherwise with a transport created by NewTransport
+// from TransportConfig (connection pooling, HTTP/2, dial, TLS handshake and response header timeouts)
+// or with net/http.DefaultTransport.
 //
-// If ClientConfig.RetriesConfig has any errors, those will be also returned to the caller,
-// not providing those values is not possible as retries are required on all of the endpoints.
+// If TLS is provided (client certificates, root CAs etc.), it's applied to the transport created from TransportConfig,
+// combining it with a custom Transport returns TransportConflictError. Errors of NewTLSConfig are returned as well.
 //
-// If Headers won't be empty, all the headers will be set on every outgoing http request.
+// If ClientConfig.Retries has any errors, those will be also returned to the caller.
+// If it's nil, retry.DefaultRetriesConfig is used, MaxRetries of 0 disables retries.
+//
+// RetryPolicy decides which attempts are retried, if it's not provided DefaultRetryPolicy is used which never retries
+// non-idempotent requests (POST without `Idempotency-Key` header provided by the caller). It can be overridden per call
+// with WithRetryPolicy.
+//
+// Every POST and PATCH call gets `Idempotency-Key` header (generated or provided with WithIdempotencyKey),
+// which is shared by all of its attempts, unless DisableIdempotencyKeys is set. Only provided keys make the call
+// retryable by DefaultRetryPolicy, as not every server honours the header.
+//
+// If Headers won't be empty, all the headers will be set on every outgoing http request unless the request sets them itself.
+//
+// Metadata of calls (status, headers, attempts etc.) can be captured with WithResponseInfo,
+// the server's request id is read from RequestIdHeader, DefaultRequestIdHeader if it's not provided.
 //
 // If Logging is enabled, every outgoing request will be logged along with its execution time, including retries.
+// Records are written to Logger, or to the standard logger on logging.Info level if it's not provided.
+// Setting Logger alone enables logging as well. URLs and bodies are redacted before being logged.
+//
+// If Metrics are provided, every call will be measured along with its retries, see PrometheusMetrics.
+//
+// Every call gets a span from Tracer and every attempt gets its child span which is propagated with W3C `traceparent`
+// and `tracestate` headers. If Tracer is not provided, NoopTracer is used so span context from the caller's context
+// (see ContextWithSpanContext) is still propagated.
+//
+// If Auth is provided, it will authorise every attempt of outgoing http requests.
+//
+// If Signer is provided, it will sign every attempt of outgoing http requests, after Auth.
+//
+// If CoalesceRequests is enabled, concurrent identical GET calls (same URL, call headers, dry run and WithRetries) share
+// one call along with its retries and result, calls made WithRetryPolicy are never shared. The shared call runs with
+// a context detached from all the callers, which keeps only their operation, dry run, headers and correlation id,
+// so a cancelled caller returns early without aborting the call for the others. It's bounded by Timeout of every attempt
+// and by CallTimeout.
+//
+// Calls made with WithDryRun build their requests but don't send them, see DryRunError.
+//
+// If Failover is provided, calls made against its first endpoint are routed to the first healthy one, see Failover.
+// The endpoint which served the call is reported in ResponseInfo.Endpoint, Close stops probing of the endpoints.
+//
+// Middlewares wrap every attempt (including retries), CallMiddlewares wrap the whole call along with its retries.
+// Every attempt goes through the chain in the following order:
+// metrics, tracing, CallMiddlewares, idempotency key, retries, failover, tracing, Headers, Auth, Middlewares, Signer,
+// logging and finally the network.
 func NewClient(config ClientConfig) (*Client, error) {
 	if config.Timeout.Milliseconds() <= 0 {
 		return nil, TimeoutZeroError
 	}
 
-	retry, err := retry.NewRetries(config.Retries)
+	retries := config.Retries
+	if retries == nil {
+		retries = retry.DefaultRetriesConfig()
+	}
+	retry, err := retry.NewRetries(retries)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		client:  &corehttp.Client{Timeout: config.Timeout},
-		retry:   retry,
-		headers: config.Headers,
-		logging: config.Logging,
-	}, nil
+	retryPolicy := config.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = DefaultRetryPolicy
+	}
+
+	requestIdHeader := config.RequestIdHeader
+	if len(requestIdHeader) == 0 {
+		requestIdHeader = DefaultRequestIdHeader
+	}
+
+	transport := config.Transport
+	if config.TLS != nil {
+		if transport != nil {
+			return nil, TransportConflictError
+		}
+		tlsConfig, err := NewTLSConfig(*config.TLS)
+		if err != nil {
+			return nil, err
+		}
+		var transportConfig TransportConfig
+		if config.TransportConfig != nil {
+			transportConfig = *config.TransportConfig
+		}
+		transportConfig.TLSClientConfig = tlsConfig
+		transport = NewTransport(transportConfig)
+	} else if transport == nil && config.TransportConfig != nil {
+		transport = NewTransport(*config.TransportConfig)
+	}
+
+	client := &Client{
+		client:          &corehttp.Client{Timeout: config.Timeout, Transport: transport},
+		retry:           retry,
+		retryPolicy:     retryPolicy,
+		callTimeout:     config.CallTimeout,
+		requestIdHeader: requestIdHeader,
+	}
+	if config.CoalesceRequests {
+		client.flights = &flightGroup{flights: make(map[string]*flight)}
+	}
+
+	var callMiddlewares, attemptMiddlewares []Middleware
+	if config.Failover != nil {
+		failover, err := NewFailover(*config.Failover, transport)
+		if err != nil {
+			return nil, err
+		}
+		failover.metrics = config.Metrics
+		client.failover = failover
+		attemptMiddlewares = append(attemptMiddlewares, failover.Middleware())
+	}
+	if config.Metrics != nil {
+		callMiddlewares = append(callMiddlewares, metricsMiddleware(config.Metrics))
+		attemptMiddlewares = append(attemptMiddlewares, retryMetricsMiddleware(config.Metrics))
+	}
+	tracer := config.Tracer
+	if tracer == nil {
+		tracer = NoopTracer()
+	}
+	callMiddlewares = append(callMiddlewares, callTracingMiddleware(tracer))
+	callMiddlewares = append(callMiddlewares, config.CallMiddlewares...)
+	if !config.DisableIdempotencyKeys {
+		callMiddlewares = append(callMiddlewares, idempotencyMiddleware())
+	}
+
+	attemptMiddlewares = append(attemptMiddlewares, attemptTracingMiddleware(tracer))
+	attemptMiddlewares = append(attemptMiddlewares, defaultHeadersMiddleware(config.Headers))
+	if config.Auth != nil {
+		attemptMiddlewares = append(attemptMiddlewares, authMiddleware(config.Auth, config.Metrics))
+	}
+	attemptMiddlewares = append(attemptMiddlewares, config.Middlewares...)
+	if config.Signer != nil {
+		attemptMiddlewares = append(attemptMiddlewares, signerMiddleware(config.Signer))
+	}
+	if config.Logger != nil {
+		attemptMiddlewares = append(attemptMiddlewares, loggingMiddleware(config.Logger, requestIdHeader))
+	} else if config.Logging {
+		attemptMiddlewares = append(attemptMiddlewares, loggingMiddleware(logging.NewStdLogger(nil, logging.Info), requestIdHeader))
+	}
+
+	client.attempts = Chain(RoundTripperFunc(client.send), attemptMiddlewares...)
+	client.transport = Chain(RoundTripperFunc(client.executeWithRetry), callMiddlewares...)
+	return client, nil
+}
+
+// Returns Failover created from ClientConfig.Failover, nil if it's not configured
+func (c *Client) Failover() *Failover {
+	return c.failover
+}
+
+// Releases background resources of the Client, e.g. stops probing of Failover endpoints
+func (c *Client) Close() {
+	if c.failover != nil {
+		c.failover.Close()
+	}
 }
 
 // Runs GET HTTP query for provided url, responseBody (pointer) will be written by json.Unmarshal.
@@ -80,12 +270,7 @@ func (c *Client) Get(ctx context.Context, url *url.URL, responseBody interface{}
 		return err
 	}
 
-	err = c.executeWithRetry(request, responseBody)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return c.execute(request, responseBody)
 }
 
 // Runs DELETE HTTP query for provided url.
@@ -100,11 +285,7 @@ func (c *Client) Delete(ctx context.Context, url *url.URL) error {
 		return err
 	}
 
-	err = c.executeWithRetry(request, nil)
-	if err != nil {
-		return err
-	}
-	return nil
+	return c.execute(request, nil)
 }
 
 // Runs POST HTTP query for provided url, responseBody (pointer) will be written by json.Unmarshal.
@@ -119,94 +300,256 @@ func (c *Client) Post(ctx context.Context, url *url.URL, requestBody interface{}
 		return err
 	}
 
-	err = c.executeWithRetry(request, responseBody)
+	return c.execute(request, responseBody)
+}
+
+// Runs PUT HTTP query for provided url, responseBody (pointer) will be written by json.Unmarshal.
+//
+// In case of network, parsing or io error (non http related) it will return ClientError.
+//
+// In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
+func (c *Client) Put(ctx context.Context, url *url.URL, requestBody interface{}, responseBody interface{}) error {
+	method := "PUT"
+	request, err := c.createRequest(ctx, method, url, requestBody)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return c.execute(request, responseBody)
+}
+
+// Runs PATCH HTTP query for provided url, responseBody (pointer) will be written by json.Unmarshal.
+//
+// In case of network, parsing or io error (non http related) it will return ClientError.
+//
+// In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
+func (c *Client) Patch(ctx context.Context, url *url.URL, requestBody interface{}, responseBody interface{}) error {
+	method := "PATCH"
+	request, err := c.createRequest(ctx, method, url, requestBody)
+	if err != nil {
+		return err
+	}
+
+	return c.execute(request, responseBody)
+}
+
+// Runs HEAD HTTP query for provided url and returns headers of the response.
+//
+// In case of network or io error (non http related) it will return ClientError.
+//
+// In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
+func (c *Client) Head(ctx context.Context, url *url.URL) (corehttp.Header, error) {
+	response, err := c.Do(ctx, Request{Method: "HEAD", Url: url})
+	if response == nil {
+		return nil, err
+	}
+	return response.Header, err
+}
+
+// Runs HTTP query described by the Request, it goes through the same retries and middlewares as other operations.
+//
+// Response is returned whenever the server responded, also along with ClientHttpError or parsing ClientError.
+//
+// In case of network, parsing or io error (non http related) it will return ClientError.
+//
+// In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
+func (c *Client) Do(ctx context.Context, request Request) (*Response, error) {
+	if request.Url == nil {
+		return nil, UrlEmptyError
+	}
+	method := request.Method
+	if len(method) == 0 {
+		method = "GET"
+	}
+
+	url := *request.Url
+	if len(request.Query) > 0 {
+		query := url.Query()
+		for key, values := range request.Query {
+			query[key] = append(query[key], values...)
+		}
+		url.RawQuery = query.Encode()
+	}
+
+	var body io.Reader
+	if request.Body != nil {
+		marshaledBody, err := json.Marshal(request.Body)
+		if err != nil {
+			return nil, &ClientError{Message: "body parse error", Class: BodyErrorClass, Url: &url, Err: err}
+		}
+		body = bytes.NewReader(marshaledBody)
+	}
+
+	httpRequest, err := corehttp.NewRequestWithContext(ctx, method, url.String(), body)
+	if err != nil {
+		return nil, &ClientError{Message: "network error", Class: NetworkErrorClass, Url: &url, Err: err}
+	}
+	for key, value := range request.Headers {
+		httpRequest.Header.Set(key, value)
+	}
+	return c.do(httpRequest, request.ResponseBody)
 }
 
 func (c *Client) createRequest(context context.Context, method string, url *url.URL, requestBody interface{}) (resp *corehttp.Request, err error) {
 	marshaledBody, err := json.Marshal(requestBody)
 
 	if err != nil {
-		return nil, &ClientError{Message: "body parse error", Url: url, Err: err}
+		return nil, &ClientError{Message: "body parse error", Class: BodyErrorClass, Url: url, Err: err}
 	}
 
 	req, err := corehttp.NewRequestWithContext(context, method, url.String(), bytes.NewBuffer(marshaledBody))
 	if err != nil {
-		return nil, &ClientError{Message: "network error", Url: url, Err: err}
+		return nil, &ClientError{Message: "network error", Class: NetworkErrorClass, Url: url, Err: err}
 	}
-	c.setHeaders(req)
 	return req, nil
 }
 
-func (c *Client) setHeaders(req *corehttp.Request) {
-	for key, value := range c.headers {
-		req.Header.Set(key, value)
+func (c *Client) execute(request *corehttp.Request, responseBody interface{}) error {
+	_, err := c.do(request, responseBody)
+	return err
+}
+
+func (c *Client) do(request *corehttp.Request, responseBody interface{}) (*Response, error) {
+	if c.callTimeout > 0 {
+		ctx, cancel := context.WithTimeout(request.Context(), c.callTimeout)
+		defer cancel()
+		request = request.WithContext(ctx)
 	}
+	for key, value := range headersFromContext(request.Context()) {
+		request.Header.Set(key, value)
+	}
+	retries, err := retriesFromContext(request.Context(), c.retry)
+	if err != nil {
+		return nil, err
+	}
+	request, state := withCallState(request)
+	state.retries = retries
+	startTime := time.Now()
+	var response *corehttp.Response
+	if c.flights != nil && request.Method == "GET" {
+		response, err = c.coalesce(request, state)
+	} else {
+		response, err = c.transport.RoundTrip(request)
+	}
+
+	info := ResponseInfo{Url: state.url, Endpoint: state.endpoint, Attempts: state.attempts, Elapsed: time.Now().Sub(startTime)}
+	if response != nil {
+		info.StatusCode = response.StatusCode
+		info.Header = response.Header
+		info.RequestId = response.Header.Get(c.requestIdHeader)
+	}
+	if target := responseInfoFromContext(request.Context()); target != nil {
+		*target = info
+	}
+
+	body, err := c.readResponse(response, err, request.URL, responseBody)
+	if body == nil {
+		return nil, err
+	}
+	return &Response{ResponseInfo: info, Body: body}, err
 }
 
-func (c *Client) executeWithRetry(request *corehttp.Request, responseBody interface{}) error {
-	return c.retry.Execute(func() error {
-		c.logNewRequest(request.Method, request.URL)
-		startTime := time.Now()
-		response, err := c.client.Do(request)
-		c.logFinishedRequest(request.Method, request.URL, time.Now().Sub(startTime), response)
-		encapsulatedErr := c.readResponse(response, err, request.URL, responseBody)
+// Runs attempts until one of them succeeds or retries are exhausted, the last response is returned with its body
+// read into memory, non-2xx responses are not treated as errors in here so call middlewares can see them.
+func (c *Client) executeWithRetry(request *corehttp.Request) (*corehttp.Response, error) {
+	request, err := rewindable(request)
+	if err != nil {
+		return nil, err
+	}
 
-		if c.shouldRetry(encapsulatedErr) {
-			return &retry.RetryableError{Err: encapsulatedErr}
+	var response *corehttp.Response
+	state := callStateFromContext(request.Context())
+	retries := state.retries
+	if retries == nil {
+		retries = c.retry
+	}
+	retryPolicy := retryPolicyFromContext(request.Context(), c.retryPolicy)
+	err = retries.ExecuteContext(request.Context(), func() error {
+		attempt, err := rewind(request)
+		if err != nil {
+			return err
 		}
-		return encapsulatedErr
-	})
-}
+		state.attempts++
+		state.url = attempt.URL
+		attempt = withAttempt(attempt, state.attempts)
 
-func (c *Client) logNewRequest(method string, url *url.URL) {
-	if c.logging {
-		log.Printf("Outgoing request to [%s] [%s] \n", method, url)
+		response, err = c.executeAttempt(attempt)
+		if request.Context().Err() != nil || isDryRun(err) {
+			return err
+		}
+		if retryPolicy(newAttemptResult(request, state.attempts, response, err)) {
+			return &retry.RetryableError{Err: err}
+		}
+		return err
+	})
+	if err != nil && response == nil {
+		return nil, err
 	}
+	return response, nil
 }
 
-func (c *Client) logFinishedRequest(method string, url *url.URL, elapsed time.Duration, response *corehttp.Response) {
-	if !c.logging {
-		return
+func (c *Client) executeAttempt(request *corehttp.Request) (*corehttp.Response, error) {
+	response, err := c.attempts.RoundTrip(request)
+	var clientError *ClientError
+	if errors.As(err, &clientError) {
+		return nil, clientError
 	}
-	if response != nil && response.StatusCode >= 400 {
-		log.Printf("Outgoing request to [%s] [%s] failed with status [%d] in [%s] \n", method, url, response.StatusCode, elapsed.String())
-	} else {
-		log.Printf("Outgoing request to [%s] [%s] completed in [%s] \n", method, url, elapsed.String())
+	var dryRunError *DryRunError
+	if errors.As(err, &dryRunError) {
+		return nil, dryRunError
+	}
+	if err != nil || response == nil {
+		return nil, &ClientError{Message: "network error", Class: NetworkErrorClass, Url: request.URL, Err: err, IsRetryable: true}
+	}
+
+	buffer, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, &ClientError{Message: "io error", Class: IoErrorClass, Url: request.URL, Err: err, IsRetryable: false}
 	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(buffer))
+	return response, nil
 }
 
-func (c *Client) readResponse(response *corehttp.Response, err error, url *url.URL, responseBody interface{}) error {
+// Returns the response body (nil if it couldn't be read) along with an error
+func (c *Client) readResponse(response *corehttp.Response, err error, url *url.URL, responseBody interface{}) ([]byte, error) {
+	var clientError *ClientError
+	if errors.As(err, &clientError) {
+		return nil, clientError
+	}
+	var dryRunError *DryRunError
+	if errors.As(err, &dryRunError) {
+		return nil, dryRunError
+	}
 	if err != nil || response == nil {
-		return &ClientError{Message: "network error", Url: url, Err: err, IsRetryable: true}
+		return nil, &ClientError{Message: "network error", Class: NetworkErrorClass, Url: url, Err: err, IsRetryable: true}
 	}
 
 	buffer, err := ioutil.ReadAll(response.Body)
 	defer response.Body.Close()
 
 	if err != nil {
-		return &ClientError{Message: "io error", Url: url, Err: err, IsRetryable: false}
+		return nil, &ClientError{Message: "io error", Class: IoErrorClass, Url: url, Err: err, IsRetryable: false}
+	}
+	if buffer == nil {
+		buffer = []byte{}
 	}
 
 	if response.StatusCode >= 400 {
-		return &ClientHttpError{Url: url, StatusCode: response.StatusCode, ResponseBody: buffer, IsRetryable: response.StatusCode >= 500}
+		return buffer, &ClientHttpError{Url: url, StatusCode: response.StatusCode, ResponseBody: buffer, IsRetryable: response.StatusCode >= 500}
 	}
 
 	if responseBody == nil {
-		return nil
+		return buffer, nil
 	}
 	err = json.Unmarshal(buffer, responseBody)
 	if err != nil {
-		return &ClientError{Message: "parsing error", Url: url, Err: err, IsRetryable: false}
+		return buffer, &ClientError{Message: "parsing error", Class: ParsingErrorClass, Url: url, Err: err, IsRetryable: false}
 	}
-	return nil
+	return buffer, nil
 }
 
-func (c *Client) shouldRetry(err error) bool {
+func isRetryable(err error) bool {
 	switch err.(type) {
 	case *ClientError:
 		var clientError *ClientError
@@ -337,8 +337,8 @@ func TestClient_PostWithHttpError(t *testing.T) {
 	}{
 		{StatusCode: 400, CallCount: 1, Url: serverUrl.ResolveReference(createUrl("400")), ExpectedError: &ClientHttpError{Url: serverUrl.ResolveReference(createUrl("400")), StatusCode: 400}},
 		{StatusCode: 404, CallCount: 1, Url: serverUrl.ResolveReference(createUrl("404")), ExpectedError: &ClientHttpError{Url: serverUrl.ResolveReference(createUrl("404")), StatusCode: 404}},
-		{StatusCode: 500, CallCount: 4, Url: serverUrl.ResolveReference(createUrl("500")), ExpectedError: &ClientHttpError{Url: serverUrl.ResolveReference(createUrl("500")), StatusCode: 500}},
-		{StatusCode: 503, CallCount: 4, Url: serverUrl.ResolveReference(createUrl("503")), ExpectedError: &ClientHttpError{Url: serverUrl.ResolveReference(createUrl("503")), StatusCode: 503}},
+		{StatusCode: 500, CallCount: 1, Url: serverUrl.ResolveReference(createUrl("500")), ExpectedError: &ClientHttpError{Url: serverUrl.ResolveReference(createUrl("500")), StatusCode: 500}},
+		{StatusCode: 503, CallCount: 1, Url: serverUrl.ResolveReference(createUrl("503")), ExpectedError: &ClientHttpError{Url: serverUrl.ResolveReference(createUrl("503")), StatusCode: 503}},
 	}
 
 	for _, testCase := range testCases {
@@ -441,6 +441,130 @@ func TestClient_PostWithDialError(t *testing.T) {
 	assert.Equal(t, 0, callCount["/"])
 }
 
+func TestClient_PutAndPatch(t *testing.T) {
+	t.Logf("Given HTTP server echoing request bodies")
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		methods = append(methods, req.Method)
+		var body DummyResponse
+		json.NewDecoder(req.Body).Decode(&body)
+		json.NewEncoder(res).Encode(body)
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling PUT and PATCH")
+	var putResponse, patchResponse DummyResponse
+	putErr := client.Put(context.Background(), serverUrl, DummyResponse{Id: 1, Title: "Jan"}, &putResponse)
+	patchErr := client.Patch(context.Background(), serverUrl, DummyResponse{Id: 2, Title: "Kowalski"}, &patchResponse)
+
+	t.Logf("Should send bodies and decode responses")
+	assert.NoError(t, putErr)
+	assert.NoError(t, patchErr)
+	assert.Equal(t, DummyResponse{Id: 1, Title: "Jan"}, putResponse)
+	assert.Equal(t, DummyResponse{Id: 2, Title: "Kowalski"}, patchResponse)
+	assert.Equal(t, []string{"PUT", "PATCH"}, methods)
+}
+
+func TestClient_Head(t *testing.T) {
+	t.Logf("Given HTTP server")
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("ETag", `"1"`)
+		if req.Method != "HEAD" || req.ContentLength != 0 {
+			res.WriteHeader(400)
+		}
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling HEAD")
+	headers, err := client.Head(context.Background(), serverUrl)
+
+	t.Logf("Should return response headers")
+	assert.NoError(t, err)
+	assert.Equal(t, `"1"`, headers.Get("ETag"))
+}
+
+func TestClient_Do(t *testing.T) {
+	t.Logf("Given HTTP server")
+	var request *http.Request
+	var requestBody DummyResponse
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		request = req
+		json.NewDecoder(req.Body).Decode(&requestBody)
+		res.Header().Set("X-Request-Id", "42")
+		res.WriteHeader(201)
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL + "/dummies?page=1")
+	defer server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling Do with query, headers and body")
+	var dummyResponse DummyResponse
+	response, err := client.Do(context.Background(), Request{
+		Method:       "PUT",
+		Url:          serverUrl,
+		Query:        url.Values{"filter": []string{"jan"}},
+		Headers:      Headers{"Content-Type": "application/merge-patch+json"},
+		Body:         DummyResponse{Id: 1, Title: "Jan"},
+		ResponseBody: &dummyResponse,
+	})
+
+	t.Logf("Should send the request with request headers taking precedence")
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT", request.Method)
+	assert.Equal(t, "/dummies", request.URL.Path)
+	assert.Equal(t, "1", request.URL.Query().Get("page"))
+	assert.Equal(t, "jan", request.URL.Query().Get("filter"))
+	assert.Equal(t, "application/merge-patch+json", request.Header.Get("Content-Type"))
+	assert.Equal(t, "application/json", request.Header.Get("Accept"))
+	assert.Equal(t, DummyResponse{Id: 1, Title: "Jan"}, requestBody)
+
+	t.Logf("And should return decoded response with metadata")
+	assert.Equal(t, DummyResponse{Id: 1, Title: "Jan"}, dummyResponse)
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, "42", response.RequestId)
+	assert.Equal(t, 1, response.Attempts)
+	assert.JSONEq(t, `{"id": 1, "title": "Jan"}`, string(response.Body))
+	assert.Equal(t, "page=1", serverUrl.RawQuery)
+}
+
+func TestClient_DoWithHttpError(t *testing.T) {
+	t.Logf("Given HTTP server returning 404 status")
+	callCount := make(map[string]int)
+	server := httptest.NewServer(requestHandlerWithBody(404, &callCount, DummyResponse{Title: "missing"}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling Do without a method")
+	response, err := client.Do(context.Background(), Request{Url: serverUrl})
+
+	t.Logf("Should return ClientHttpError along with the response")
+	var httpError *ClientHttpError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Equal(t, 404, response.StatusCode)
+	assert.JSONEq(t, `{"id": 0, "title": "missing"}`, string(response.Body))
+
+	t.Logf("When calling Do without Url")
+	response, err = client.Do(context.Background(), Request{Method: "GET"})
+
+	t.Logf("Should return UrlEmptyError")
+	assert.Equal(t, UrlEmptyError, err)
+	assert.Nil(t, response)
+}
+
 func requestHandler(statusCode int, callCount *map[string]int) http.HandlerFunc {
 	return requestHandlerWithBody(statusCode, callCount, nil)
 }
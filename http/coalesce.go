@@ -0,0 +1,123 @@
+package http
+
+import (
+	"accountapi-client/retry"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	corehttp "net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Identical GET calls in flight, see ClientConfig.CoalesceRequests
+type flightGroup struct {
+	mutex   sync.Mutex
+	flights map[string]*flight
+}
+
+// Shared call, its results are written before done is closed
+type flight struct {
+	done     chan struct{}
+	response *corehttp.Response
+	body     []byte
+	err      error
+	state    callState
+}
+
+// Joins the in-flight call identical to the request or starts a new one. The shared call runs with a context detached
+// from the callers, so cancelling a caller stops only its own waiting. Calls overriding RetryPolicy are never shared
+// as policies can't be compared.
+func (c *Client) coalesce(request *corehttp.Request, state *callState) (*corehttp.Response, error) {
+	if _, ok := request.Context().Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return c.transport.RoundTrip(request)
+	}
+	key := flightKey(request)
+	c.flights.mutex.Lock()
+	shared, ok := c.flights.flights[key]
+	if !ok {
+		shared = &flight{done: make(chan struct{})}
+		c.flights.flights[key] = shared
+		go c.fly(key, shared, request, state.retries)
+	}
+	c.flights.mutex.Unlock()
+
+	select {
+	case <-shared.done:
+	case <-request.Context().Done():
+		return nil, request.Context().Err()
+	}
+	state.attempts = shared.state.attempts
+	state.url = shared.state.url
+	state.endpoint = shared.state.endpoint
+	if shared.err != nil {
+		return nil, shared.err
+	}
+	response := *shared.response
+	response.Header = shared.response.Header.Clone()
+	response.Body = ioutil.NopCloser(bytes.NewReader(shared.body))
+	return &response, nil
+}
+
+// Runs the shared call with settings of the caller which started it, they are the same for all of the callers
+func (c *Client) fly(key string, shared *flight, request *corehttp.Request, retries *retry.Retry) {
+	ctx := sharedContext(request.Context())
+	if c.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+	}
+	request, state := withCallState(request.WithContext(ctx))
+	state.retries = retries
+
+	response, err := c.transport.RoundTrip(request)
+	if err == nil && response != nil {
+		shared.body, err = ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			err = &ClientError{Message: "io error", Class: IoErrorClass, Url: request.URL, Err: err, IsRetryable: false}
+		}
+	}
+	if err == nil && response == nil {
+		err = &ClientError{Message: "network error", Class: NetworkErrorClass, Url: request.URL, IsRetryable: true}
+	}
+	shared.response, shared.err, shared.state = response, err, *state
+
+	c.flights.mutex.Lock()
+	delete(c.flights.flights, key)
+	c.flights.mutex.Unlock()
+	close(shared.done)
+}
+
+// Identifies identical calls by their URL, headers set for the call, dry run and retries
+func flightKey(request *corehttp.Request) string {
+	lines := []string{
+		request.Method + " " + request.URL.String(),
+		"dry-run: " + strconv.FormatBool(dryRunFromContext(request.Context())),
+		"retries: ",
+	}
+	if config, ok := request.Context().Value(retriesKey{}).(*retry.RetriesConfig); ok && config != nil {
+		lines[2] += fmt.Sprintf("%+v", *config)
+	}
+	for name, values := range request.Header {
+		lines = append(lines, name+": "+strings.Join(values, ", "))
+	}
+	sort.Strings(lines[3:])
+	return strings.Join(lines, "\n")
+}
+
+// Returns a context without deadline and cancellation of the caller which carries only values shared by all the callers,
+// values owned by the caller (e.g. its ResponseInfo, call state or tracing span) are dropped as the shared call
+// may outlive it.
+func sharedContext(ctx context.Context) context.Context {
+	shared := context.Background()
+	for _, key := range []interface{}{operationKey{}, dryRunKey{}, headersKey{}, correlationIdKey{}} {
+		if value := ctx.Value(key); value != nil {
+			shared = context.WithValue(shared, key, value)
+		}
+	}
+	return shared
+}
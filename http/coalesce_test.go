@@ -0,0 +1,220 @@
+package http
+
+import (
+	"accountapi-client/retry"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_GetWithCoalescing(t *testing.T) {
+	t.Logf("Given HTTP server blocking requests until released")
+	var calls int32
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		received <- struct{}{}
+		<-release
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client coalescing requests")
+	config := validClientConfig
+	config.CoalesceRequests = true
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET concurrently")
+	responses := make([]DummyResponse, 5)
+	infos := make([]ResponseInfo, 5)
+	errs := make([]error, 5)
+	var wait sync.WaitGroup
+	call := func(i int) {
+		defer wait.Done()
+		errs[i] = client.Get(WithResponseInfo(context.Background(), &infos[i]), serverUrl, &responses[i])
+	}
+	wait.Add(5)
+	go call(0)
+	<-received
+	for i := 1; i < 5; i++ {
+		go call(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wait.Wait()
+
+	t.Logf("Should make a single call and share its result")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, DummyResponse{Id: 1, Title: "Jan"}, responses[i])
+		assert.Equal(t, 200, infos[i].StatusCode)
+		assert.Equal(t, 1, infos[i].Attempts)
+	}
+
+	t.Logf("When calling GET again")
+	err := client.Get(context.Background(), serverUrl, &DummyResponse{})
+
+	t.Logf("Should make a new call")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestClient_GetWithCoalescingAndCancellation(t *testing.T) {
+	t.Logf("Given HTTP server blocking requests until released")
+	var calls int32
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		received <- struct{}{}
+		<-release
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client coalescing requests")
+	config := validClientConfig
+	config.CoalesceRequests = true
+	client, _ := NewClient(config)
+
+	t.Logf("When the caller which started the call cancels it while another one waits for it")
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		firstErr <- client.Get(ctx, serverUrl, &DummyResponse{})
+	}()
+	<-received
+	var second DummyResponse
+	secondErr := make(chan error)
+	go func() {
+		secondErr <- client.Get(context.Background(), serverUrl, &second)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	t.Logf("Should return early only for the cancelled caller")
+	assert.True(t, errors.Is(<-firstErr, context.Canceled))
+	close(release)
+	assert.NoError(t, <-secondErr)
+	assert.Equal(t, DummyResponse{Id: 1, Title: "Jan"}, second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_GetWithCoalescingAndCancelledFirstCaller(t *testing.T) {
+	t.Logf("Given HTTP server blocking requests until released")
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		received <- struct{}{}
+		<-release
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client coalescing requests")
+	config := validClientConfig
+	config.CoalesceRequests = true
+	client, _ := NewClient(config)
+
+	t.Logf("When the caller which started the call with its ResponseInfo and retries cancels while another one waits")
+	var firstInfo ResponseInfo
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithResponseInfo(ctx, &firstInfo)
+	ctx = WithRetries(ctx, &retry.RetriesConfig{MaxRetries: 1, Delay: time.Millisecond, Factor: 1})
+	firstErr := make(chan error)
+	go func() {
+		firstErr <- client.Get(ctx, serverUrl, &DummyResponse{})
+	}()
+	<-received
+	var secondInfo ResponseInfo
+	secondCtx := WithResponseInfo(context.Background(), &secondInfo)
+	secondCtx = WithRetries(secondCtx, &retry.RetriesConfig{MaxRetries: 1, Delay: time.Millisecond, Factor: 1})
+	secondErr := make(chan error)
+	go func() {
+		secondErr <- client.Get(secondCtx, serverUrl, &DummyResponse{})
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	assert.True(t, errors.Is(<-firstErr, context.Canceled))
+	returnedInfo := firstInfo
+	close(release)
+
+	t.Logf("Should fill only the ResponseInfo of the caller which got the result")
+	assert.NoError(t, <-secondErr)
+	assert.Equal(t, returnedInfo, firstInfo)
+	assert.Equal(t, 200, secondInfo.StatusCode)
+	assert.Equal(t, 1, secondInfo.Attempts)
+}
+
+func TestSharedContext(t *testing.T) {
+	t.Logf("Given context of a caller with values and deadline")
+	var info ResponseInfo
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	ctx = WithOperation(WithDryRun(WithHeaders(ctx, Headers{"X-Tenant": "eu"}), true), "account.fetch")
+	ctx = WithResponseInfo(ContextWithSpanContext(ctx, SpanContext{}), &info)
+
+	t.Logf("When creating the context of a shared call")
+	shared := sharedContext(ctx)
+
+	t.Logf("Should keep only values shared by all the callers")
+	_, hasDeadline := shared.Deadline()
+	assert.False(t, hasDeadline)
+	assert.Equal(t, "account.fetch", shared.Value(operationKey{}))
+	assert.True(t, dryRunFromContext(shared))
+	assert.Equal(t, Headers{"X-Tenant": "eu"}, headersFromContext(shared))
+	assert.Nil(t, responseInfoFromContext(shared))
+	_, hasSpan := SpanContextFromContext(shared)
+	assert.False(t, hasSpan)
+}
+
+func TestClient_GetWithCoalescingDifferentCalls(t *testing.T) {
+	t.Logf("Given HTTP server")
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	otherUrl, _ := url.Parse(server.URL + "/other")
+	defer server.Close()
+
+	t.Logf("And given Client coalescing requests")
+	config := validClientConfig
+	config.CoalesceRequests = true
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET concurrently with different URLs, headers, retries and retry policies")
+	var wait sync.WaitGroup
+	for _, ctx := range []context.Context{
+		context.Background(),
+		WithHeaders(context.Background(), Headers{"X-Tenant": "eu"}),
+		WithRetries(context.Background(), &retry.RetriesConfig{MaxRetries: 1, Delay: time.Millisecond, Factor: 1}),
+		WithRetryPolicy(context.Background(), NoRetryPolicy),
+	} {
+		for _, target := range []*url.URL{serverUrl, otherUrl} {
+			wait.Add(1)
+			go func(ctx context.Context, target *url.URL) {
+				defer wait.Done()
+				client.Get(ctx, target, &DummyResponse{})
+			}(ctx, target)
+		}
+	}
+	wait.Wait()
+
+	t.Logf("Should not share them")
+	assert.Equal(t, int32(8), atomic.LoadInt32(&calls))
+}
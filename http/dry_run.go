@@ -0,0 +1,61 @@
+package http
+
+import (
+	"accountapi-client/logging"
+	"context"
+	"errors"
+	corehttp "net/http"
+	"sort"
+	"strings"
+)
+
+// Headers which values are redacted when a dry-run request is logged
+var SensitiveHeaders = []string{"Authorization", "Signature", "Cookie", "Proxy-Authorization"}
+
+type dryRunKey struct{}
+
+// Returns a copy of the context which makes the Client build the requests of the call but not send them.
+// The call goes through default headers, Auth, Middlewares, Signer and logging as usual and returns DryRunError
+// with the request exactly as it would have been sent, it's never retried.
+func WithDryRun(ctx context.Context, dryRun bool) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, dryRun)
+}
+
+func dryRunFromContext(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
+
+func isDryRun(err error) bool {
+	var dryRunError *DryRunError
+	return errors.As(err, &dryRunError)
+}
+
+// Sends the request, unless it's a dry run, used as the innermost transport of every attempt
+func (c *Client) send(request *corehttp.Request) (*corehttp.Response, error) {
+	if !dryRunFromContext(request.Context()) {
+		return c.client.Do(request)
+	}
+	return nil, &DryRunError{
+		Method: request.Method,
+		Url:    request.URL,
+		Header: request.Header.Clone(),
+		Body:   peekRequestBody(request),
+	}
+}
+
+// Formats the headers as `Name: value` lines with SensitiveHeaders redacted
+func redactHeaders(header corehttp.Header) string {
+	lines := make([]string, 0, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		for _, sensitive := range SensitiveHeaders {
+			if strings.EqualFold(name, sensitive) {
+				value = logging.Redacted
+			}
+		}
+		lines = append(lines, name+": "+logging.Redact(value))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
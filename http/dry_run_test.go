@@ -0,0 +1,72 @@
+package http
+
+import (
+	"accountapi-client/logging"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestClient_PostWithDryRun(t *testing.T) {
+	t.Logf("Given HTTP server")
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with default headers, auth, metrics and logger")
+	var messages []string
+	var logged []logging.Field
+	config := validClientConfig
+	config.Headers = Headers{"Content-Type": "application/json"}
+	config.Auth = BearerToken("secret")
+	config.Metrics = NewPrometheusMetrics("")
+	config.Logger = logging.LoggerFunc(func(level logging.Level, message string, fields ...logging.Field) {
+		messages = append(messages, message)
+		logged = fields
+	})
+	client, _ := NewClient(config)
+
+	t.Logf("When calling POST in dry run")
+	var info ResponseInfo
+	ctx := WithResponseInfo(WithIdempotencyKey(WithDryRun(context.Background(), true), "key-1"), &info)
+	err := client.Post(ctx, serverUrl, &DummyRequest{Title: "Jan"}, &DummyResponse{})
+
+	t.Logf("Should return the request which would have been sent without sending it")
+	var dryRunError *DryRunError
+	assert.True(t, errors.As(err, &dryRunError))
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, 1, info.Attempts)
+	assert.Equal(t, "POST", dryRunError.Method)
+	assert.Equal(t, serverUrl.String(), dryRunError.Url.String())
+	assert.Equal(t, "application/json", dryRunError.Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer secret", dryRunError.Header.Get("Authorization"))
+	assert.Equal(t, "key-1", dryRunError.Header.Get(IdempotencyKeyHeader))
+	assert.Equal(t, `{"title":"Jan"}`, string(dryRunError.Body))
+
+	t.Logf("And should log it with redacted credentials and measure it")
+	assert.Equal(t, "Outgoing request skipped in dry run", messages[len(messages)-1])
+	var headers string
+	for _, field := range logged {
+		if field.Key == "headers" {
+			headers = field.Value.(string)
+		}
+	}
+	assert.Contains(t, headers, "Authorization: [REDACTED]")
+	assert.Contains(t, headers, "Idempotency-Key: key-1")
+	assert.True(t, strings.Contains(config.Metrics.(*PrometheusMetrics).String(), `status_class="dry_run"} 1`))
+
+	t.Logf("When calling it again with dry run disabled")
+	err = client.Post(WithDryRun(ctx, false), serverUrl, &DummyRequest{Title: "Jan"}, nil)
+
+	t.Logf("Should send it")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -3,19 +3,55 @@ package http
 import (
 	"errors"
 	"fmt"
+	corehttp "net/http"
 	"net/url"
 )
 
 // Errors thrown by NewClient when ConfigClient has errors
 var (
-	TimeoutZeroError = errors.New("timeout has to be larger than 0ms")
+	TimeoutZeroError       = errors.New("timeout has to be larger than 0ms")
+	TransportConflictError = errors.New("tls cannot be combined with a custom transport")
 )
 
+// Returned by Client.Do when Request has no Url
+var UrlEmptyError = errors.New("url cannot be empty")
+
+// Errors thrown by NewOAuth2ClientCredentials when OAuth2Config has errors
+var (
+	TokenUrlEmptyError = errors.New("tokenUrl cannot be empty")
+	ClientIdEmptyError = errors.New("clientId cannot be empty")
+)
+
+// Errors thrown by NewHttpSignatureSigner when HttpSignatureConfig has errors and by ParsePrivateKeyPem
+var (
+	KeyIdEmptyError      = errors.New("keyId cannot be empty")
+	PrivateKeyEmptyError = errors.New("privateKey cannot be empty")
+	UnsupportedKeyError  = errors.New("only RSA and Ed25519 private keys are supported")
+	InvalidPemError      = errors.New("no PEM block found")
+)
+
+// Returned by NewFailover when FailoverConfig has no endpoints
+var EndpointsEmptyError = errors.New("endpoints cannot be empty")
+
+// Errors thrown by NewTLSConfig when TLSConfig has errors
+var (
+	CertificateKeyMissingError = errors.New("certificate and key have to be provided together")
+	InvalidCaError             = errors.New("no certificates found in CA PEM")
+)
+
+// Returned by ParseTraceParent on malformed header values
+var InvalidTraceParentError = errors.New("invalid traceparent")
+
+// Wrapped in ClientError when the token endpoint responds without an access token
+var MissingAccessTokenError = errors.New("access_token is missing in the token response")
+
 // Throw by the Client on unexpected non-http related issues like parsing, dialing or tls handshake issues
 type ClientError struct {
 	Url     *url.URL
 	Message string
-	Err     error
+	// Set where the error is created, used by RetryPolicy. UnknownErrorClass is assumed if it's empty.
+	Class       ErrorClass
+	Err         error
 	IsRetryable bool
 }
 
@@ -27,6 +63,19 @@ func (e *ClientError) Unwrap() error {
 	return e.Err
 }
 
+// Returned by the Client instead of sending the request of a call made with WithDryRun,
+// Header and Body are the same as they would have been sent (including Auth and Signer headers)
+type DryRunError struct {
+	Method string
+	Url    *url.URL
+	Header corehttp.Header
+	Body   []byte
+}
+
+func (e *DryRunError) Error() string {
+	return fmt.Sprintf("dry run of %s %s, the request was not sent", e.Method, e.Url)
+}
+
 // Throw by the Client on server-side http errors, it is returned on anything beyond or equal to HTTP-400
 type ClientHttpError struct {
 	Url          *url.URL
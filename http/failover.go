@@ -0,0 +1,232 @@
+package http
+
+import (
+	"context"
+	"errors"
+	corehttp "net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of FailoverConfig
+const (
+	DefaultProbeInterval = 10 * time.Second
+	DefaultProbeTimeout  = time.Second
+)
+
+// Configures routing of calls to an ordered list of base URLs, see ClientConfig.Failover
+type FailoverConfig struct {
+	// Ordered base URLs, requests are made against the first one and routed to the first healthy one
+	Endpoints []*url.URL
+	// Path probed on unhealthy endpoints, e.g. `/v1/health`, they are restored after a 2xx response.
+	// If it's empty, unhealthy endpoints are restored after ProbeInterval without probing.
+	HealthPath string
+	// Defaults to DefaultProbeInterval
+	ProbeInterval time.Duration
+	// Defaults to DefaultProbeTimeout
+	ProbeTimeout time.Duration
+}
+
+// State of an endpoint as seen by Failover
+type EndpointStatus struct {
+	Url     *url.URL
+	Healthy bool
+	// Time of the last failure, zero if the endpoint never failed
+	FailedAt time.Time
+}
+
+// Routes attempts to the first healthy endpoint. Endpoints are marked unhealthy after network errors
+// or HTTP-5xx responses, so retries go to the next one, and restored by probing them in the background.
+// Cancelled calls and errors of the client (e.g. failed authentication) don't affect the endpoints.
+// A single endpoint has nothing to fail over to, so it's never marked unhealthy nor probed.
+type Failover struct {
+	config    FailoverConfig
+	transport corehttp.RoundTripper
+	metrics   Metrics
+	mutex     sync.Mutex
+	endpoints []EndpointStatus
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// Creates new instance of Failover probing endpoints with the transport (net/http.DefaultTransport if it's nil)
+// until Close is called.
+//
+// If FailoverConfig.Endpoints are empty it returns EndpointsEmptyError.
+func NewFailover(config FailoverConfig, transport corehttp.RoundTripper) (*Failover, error) {
+	if len(config.Endpoints) == 0 {
+		return nil, EndpointsEmptyError
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = DefaultProbeInterval
+	}
+	if config.ProbeTimeout <= 0 {
+		config.ProbeTimeout = DefaultProbeTimeout
+	}
+
+	failover := &Failover{
+		config:    config,
+		transport: transport,
+		endpoints: make([]EndpointStatus, len(config.Endpoints)),
+		stop:      make(chan struct{}),
+	}
+	for i, endpoint := range config.Endpoints {
+		failover.endpoints[i] = EndpointStatus{Url: endpoint, Healthy: true}
+	}
+	if len(config.Endpoints) > 1 {
+		go failover.probeLoop()
+	}
+	return failover, nil
+}
+
+// Returns copies of statuses of all the endpoints in their order
+func (f *Failover) Endpoints() []EndpointStatus {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]EndpointStatus{}, f.endpoints...)
+}
+
+// Stops probing endpoints
+func (f *Failover) Close() {
+	f.stopOnce.Do(func() {
+		close(f.stop)
+	})
+}
+
+// Rewrites attempts made against the first endpoint to the chosen one and records the outcome.
+// Requests to other URLs are not touched.
+func (f *Failover) Middleware() Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			primary := f.config.Endpoints[0]
+			if !hasBaseUrl(request.URL, primary) {
+				return next.RoundTrip(request)
+			}
+			index := f.choose(AttemptFromContext(request.Context()))
+			endpoint := f.config.Endpoints[index]
+
+			routed := request.Clone(request.Context())
+			routed.URL = rebase(request.URL, primary, endpoint)
+			routed.Host = ""
+			state := callStateFromContext(request.Context())
+			state.url = routed.URL
+			state.endpoint = endpoint
+
+			response, err := next.RoundTrip(routed)
+			if len(f.endpoints) > 1 && isEndpointFailure(request, response, err) {
+				f.markFailed(index)
+			}
+			return response, err
+		})
+	}
+}
+
+// Picks the first healthy endpoint, or rotates through all of them by attempt if none is healthy
+func (f *Failover) choose(attempt int) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, endpoint := range f.endpoints {
+		if endpoint.Healthy {
+			return i
+		}
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	return (attempt - 1) % len(f.endpoints)
+}
+
+// Only network errors and HTTP-5xx responses are failures of the endpoint, errors caused by the caller
+// (cancelled or expired context) or by the client itself (auth, signing, body errors, dry run) are not.
+func isEndpointFailure(request *corehttp.Request, response *corehttp.Response, err error) bool {
+	if request.Context().Err() != nil {
+		return false
+	}
+	if err == nil {
+		return response != nil && response.StatusCode >= 500
+	}
+	var clientError *ClientError
+	if errors.As(err, &clientError) {
+		return clientError.Class == NetworkErrorClass
+	}
+	return !isDryRun(err) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func (f *Failover) markFailed(index int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.endpoints[index].Healthy && f.metrics != nil {
+		f.metrics.ObserveEvent("failover", "endpoint_failed")
+	}
+	f.endpoints[index].Healthy = false
+	f.endpoints[index].FailedAt = time.Now()
+}
+
+func (f *Failover) markHealthy(index int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if !f.endpoints[index].Healthy && f.metrics != nil {
+		f.metrics.ObserveEvent("failover", "endpoint_restored")
+	}
+	f.endpoints[index].Healthy = true
+}
+
+func (f *Failover) probeLoop() {
+	ticker := time.NewTicker(f.config.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-ticker.C:
+			f.probe()
+		}
+	}
+}
+
+// Probes all the unhealthy endpoints
+func (f *Failover) probe() {
+	for i, endpoint := range f.Endpoints() {
+		if endpoint.Healthy {
+			continue
+		}
+		if len(f.config.HealthPath) == 0 || f.healthy(endpoint.Url) {
+			f.markHealthy(i)
+		}
+	}
+}
+
+func (f *Failover) healthy(endpoint *url.URL) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), f.config.ProbeTimeout)
+	defer cancel()
+
+	health := *endpoint
+	health.Path = strings.TrimSuffix(endpoint.Path, "/") + f.config.HealthPath
+	request, err := corehttp.NewRequestWithContext(ctx, "GET", health.String(), nil)
+	if err != nil {
+		return false
+	}
+	client := &corehttp.Client{Transport: f.transport}
+	response, err := client.Do(request)
+	if err != nil {
+		return false
+	}
+	response.Body.Close()
+	return response.StatusCode >= 200 && response.StatusCode < 300
+}
+
+func hasBaseUrl(requestUrl *url.URL, base *url.URL) bool {
+	return requestUrl.Scheme == base.Scheme && requestUrl.Host == base.Host &&
+		strings.HasPrefix(requestUrl.Path, strings.TrimSuffix(base.Path, "/"))
+}
+
+func rebase(requestUrl *url.URL, from *url.URL, to *url.URL) *url.URL {
+	rebased := *requestUrl
+	rebased.Scheme = to.Scheme
+	rebased.Host = to.Host
+	rebased.Path = strings.TrimSuffix(to.Path, "/") + strings.TrimPrefix(requestUrl.Path, strings.TrimSuffix(from.Path, "/"))
+	rebased.RawPath = ""
+	return &rebased
+}
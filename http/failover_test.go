@@ -0,0 +1,177 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewFailoverWithoutEndpoints(t *testing.T) {
+	t.Logf("Given FailoverConfig without endpoints")
+	config := FailoverConfig{}
+
+	t.Logf("When creating Failover")
+	failover, err := NewFailover(config, nil)
+
+	t.Logf("Should return '%s' error", EndpointsEmptyError)
+	assert.Equal(t, EndpointsEmptyError, err)
+	assert.Nil(t, failover)
+}
+
+func TestClient_GetWithFailover(t *testing.T) {
+	t.Logf("Given primary HTTP server failing until it's healthy again")
+	var primaryHealthy int32
+	primary := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if atomic.LoadInt32(&primaryHealthy) == 0 {
+			res.WriteHeader(503)
+			return
+		}
+		res.Write([]byte(`{"id": 1, "title": "primary"}`))
+	}))
+	defer primary.Close()
+
+	t.Logf("And given secondary HTTP server")
+	var secondaryPaths []string
+	secondary := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		secondaryPaths = append(secondaryPaths, req.URL.RequestURI())
+		res.Write([]byte(`{"id": 1, "title": "secondary"}`))
+	}))
+	defer secondary.Close()
+
+	t.Logf("And given Client with failover between them")
+	primaryUrl, _ := url.Parse(primary.URL + "/api")
+	secondaryUrl, _ := url.Parse(secondary.URL + "/api")
+	config := validClientConfig
+	config.Failover = &FailoverConfig{
+		Endpoints:     []*url.URL{primaryUrl, secondaryUrl},
+		HealthPath:    "/health",
+		ProbeInterval: 10 * time.Millisecond,
+	}
+	client, _ := NewClient(config)
+	defer client.Close()
+
+	t.Logf("When calling GET against the primary")
+	var info ResponseInfo
+	var dummyResponse DummyResponse
+	err := client.Get(WithResponseInfo(context.Background(), &info), createUrl(primary.URL+"/api/dummies?page=1"), &dummyResponse)
+
+	t.Logf("Should retry on the secondary and report it")
+	assert.NoError(t, err)
+	assert.Equal(t, "secondary", dummyResponse.Title)
+	assert.Equal(t, []string{"/api/dummies?page=1"}, secondaryPaths)
+	assert.Equal(t, 2, info.Attempts)
+	assert.Equal(t, secondaryUrl, info.Endpoint)
+	assert.Equal(t, secondary.URL+"/api/dummies?page=1", info.Url.String())
+	assert.False(t, client.Failover().Endpoints()[0].Healthy)
+
+	t.Logf("When the primary becomes healthy")
+	atomic.StoreInt32(&primaryHealthy, 1)
+	assert.Eventually(t, func() bool {
+		return client.Failover().Endpoints()[0].Healthy
+	}, time.Second, 5*time.Millisecond)
+	err = client.Get(WithResponseInfo(context.Background(), &info), createUrl(primary.URL+"/api/dummies"), &dummyResponse)
+
+	t.Logf("Should route calls back to it")
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", dummyResponse.Title)
+	assert.Equal(t, primaryUrl, info.Endpoint)
+}
+
+func TestClient_GetWithFailoverAndCancellation(t *testing.T) {
+	t.Logf("Given slow primary and secondary HTTP servers")
+	release := make(chan struct{})
+	primary := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+	}))
+	defer primary.Close()
+	defer close(release)
+	secondary := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	defer secondary.Close()
+
+	t.Logf("And given Client with failover between them")
+	client := newFailoverClient(primary.URL, secondary.URL, nil)
+	defer client.Close()
+
+	t.Logf("When the caller cancels a call against the primary")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := client.Get(ctx, createUrl(primary.URL+"/dummies"), nil)
+
+	t.Logf("Should keep the primary healthy")
+	assert.Error(t, err)
+	assert.True(t, client.Failover().Endpoints()[0].Healthy)
+}
+
+func TestClient_GetWithFailoverAndAuthFailure(t *testing.T) {
+	t.Logf("Given primary and secondary HTTP servers")
+	primary := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	defer secondary.Close()
+
+	t.Logf("And given Client with failover between them and failing authenticator")
+	authErr := errors.New("credentials unavailable")
+	client := newFailoverClient(primary.URL, secondary.URL, AuthenticatorFunc(func(ctx context.Context, request *http.Request) error {
+		return authErr
+	}))
+	defer client.Close()
+
+	t.Logf("When calling GET against the primary")
+	err := client.Get(context.Background(), createUrl(primary.URL+"/dummies"), nil)
+
+	t.Logf("Should return the authentication error and keep both endpoints healthy")
+	assert.True(t, errors.Is(err, authErr))
+	for _, endpoint := range client.Failover().Endpoints() {
+		assert.True(t, endpoint.Healthy)
+	}
+}
+
+func TestClient_GetWithSingleEndpointFailover(t *testing.T) {
+	t.Logf("Given HTTP server failing the first request")
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			res.WriteHeader(503)
+			return
+		}
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	defer server.Close()
+
+	t.Logf("And given Client with failover configured with the server only")
+	serverUrl, _ := url.Parse(server.URL)
+	config := validClientConfig
+	config.Failover = &FailoverConfig{Endpoints: []*url.URL{serverUrl}, ProbeInterval: time.Hour}
+	client, _ := NewClient(config)
+	defer client.Close()
+
+	t.Logf("When calling GET")
+	err := client.Get(context.Background(), createUrl(server.URL+"/dummies"), &DummyResponse{})
+
+	t.Logf("Should retry and keep the endpoint healthy")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.True(t, client.Failover().Endpoints()[0].Healthy)
+}
+
+func newFailoverClient(primary string, secondary string, auth Authenticator) *Client {
+	primaryUrl, _ := url.Parse(primary)
+	secondaryUrl, _ := url.Parse(secondary)
+	config := validClientConfig
+	config.Auth = auth
+	config.Failover = &FailoverConfig{
+		Endpoints:     []*url.URL{primaryUrl, secondaryUrl},
+		ProbeInterval: time.Hour,
+	}
+	client, _ := NewClient(config)
+	return client
+}
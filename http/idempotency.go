@@ -0,0 +1,45 @@
+package http
+
+import (
+	"context"
+	"github.com/google/uuid"
+	corehttp "net/http"
+)
+
+// Header carrying the key which lets the server recognise retries of the same logical call
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyKey struct{}
+
+type generatedIdempotencyKeyKey struct{}
+
+// Returns a copy of the context carrying the idempotency key of the call, it takes precedence over generated keys
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// Sets `Idempotency-Key` header on non-idempotent calls (POST and PATCH) once per call, so all the attempts share it.
+// The key is taken from the context (see WithIdempotencyKey), an already set header is kept,
+// otherwise a random UUID is generated. Generated keys don't make the call retryable by DefaultRetryPolicy,
+// as servers which ignore the header would duplicate side effects.
+func idempotencyMiddleware() Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			key, ok := request.Context().Value(idempotencyKeyKey{}).(string)
+			if !ok || len(key) == 0 {
+				if request.Method != "POST" && request.Method != "PATCH" {
+					return next.RoundTrip(request)
+				}
+				if len(request.Header.Get(IdempotencyKeyHeader)) > 0 {
+					return next.RoundTrip(request)
+				}
+				key = uuid.New().String()
+				request = request.WithContext(context.WithValue(request.Context(), generatedIdempotencyKeyKey{}, true))
+			}
+
+			request = request.Clone(request.Context())
+			request.Header.Set(IdempotencyKeyHeader, key)
+			return next.RoundTrip(request)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClient_PostWithGeneratedIdempotencyKey(t *testing.T) {
+	t.Logf("Given HTTP server returning 503 status")
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.Header.Get(IdempotencyKeyHeader))
+		res.WriteHeader(503)
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling POST twice")
+	client.Post(context.Background(), serverUrl, &DummyRequest{Title: "Jan"}, nil)
+	client.Post(context.Background(), serverUrl, &DummyRequest{Title: "Jan"}, nil)
+
+	t.Logf("Should send a different key with every call without retrying them")
+	assert.Equal(t, 2, len(keys))
+	assert.NotEmpty(t, keys[0])
+	assert.NotEmpty(t, keys[1])
+	assert.NotEqual(t, keys[0], keys[1])
+
+	t.Logf("When calling POST with a provided key")
+	keys = nil
+	client.Post(WithIdempotencyKey(context.Background(), "a1b2c3"), serverUrl, &DummyRequest{Title: "Jan"}, nil)
+
+	t.Logf("Should retry it with the same key")
+	assert.Equal(t, []string{"a1b2c3", "a1b2c3", "a1b2c3", "a1b2c3"}, keys)
+}
+
+func TestClient_WithProvidedIdempotencyKey(t *testing.T) {
+	t.Logf("Given HTTP server")
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.Header.Get(IdempotencyKeyHeader))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling POST with a key from the context and GET without it")
+	client.Post(WithIdempotencyKey(context.Background(), "a1b2c3"), serverUrl, &DummyRequest{Title: "Jan"}, nil)
+	client.Get(context.Background(), serverUrl, nil)
+
+	t.Logf("Should send the provided key with POST and no key with GET")
+	assert.Equal(t, []string{"a1b2c3", ""}, keys)
+}
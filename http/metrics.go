@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	corehttp "net/http"
+	"time"
+)
+
+// Receives measurements of the Client, implementations have to be safe for concurrent use.
+//
+// Operation is the name set with WithOperation, it defaults to the HTTP method.
+type Metrics interface {
+	// Called once per call (after retries) with its total latency,
+	// statusClass is one of 1xx, 2xx, 3xx, 4xx, 5xx, `error` when no response was received or `dry_run`, see WithDryRun.
+	ObserveRequest(operation string, method string, statusClass string, latency time.Duration)
+	// Called before every retry, attempt is the number of the upcoming attempt (starting from 2).
+	ObserveRetry(operation string, method string, attempt int)
+	// Called on resilience events like credentials refresh, circuit breaker or rate limiter state changes.
+	ObserveEvent(component string, event string)
+}
+
+type operationKey struct{}
+
+// Returns a copy of the context carrying the name of the operation used in Metrics and tracing, e.g. `account.create`
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// Returns the operation set with WithOperation or the request's method
+func OperationFromRequest(request *corehttp.Request) string {
+	if operation, ok := request.Context().Value(operationKey{}).(string); ok {
+		return operation
+	}
+	return request.Method
+}
+
+// Records latency and status class of the whole call, used as the outermost call middleware
+func metricsMiddleware(metrics Metrics) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			startTime := time.Now()
+			response, err := next.RoundTrip(request)
+			metrics.ObserveRequest(OperationFromRequest(request), request.Method, statusClass(response, err), time.Now().Sub(startTime))
+			return response, err
+		})
+	}
+}
+
+// Records retries, used as the outermost attempt middleware
+func retryMetricsMiddleware(metrics Metrics) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			if attempt := AttemptFromContext(request.Context()); attempt > 1 {
+				metrics.ObserveRetry(OperationFromRequest(request), request.Method, attempt)
+			}
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+func statusClass(response *corehttp.Response, err error) string {
+	if isDryRun(err) {
+		return "dry_run"
+	}
+	if err != nil || response == nil {
+		return "error"
+	}
+	switch {
+	case response.StatusCode >= 500:
+		return "5xx"
+	case response.StatusCode >= 400:
+		return "4xx"
+	case response.StatusCode >= 300:
+		return "3xx"
+	case response.StatusCode >= 200:
+		return "2xx"
+	default:
+		return "1xx"
+	}
+}
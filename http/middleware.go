@@ -0,0 +1,297 @@
+package http
+
+import (
+	"accountapi-client/logging"
+	"bytes"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"io"
+	"io/ioutil"
+	corehttp "net/http"
+	"time"
+)
+
+// Wraps the next http.RoundTripper in the chain, it can modify the request before passing it further
+// (it should work on a copy, see http.Request.Clone), inspect or replace the response and the error.
+//
+// Middlewares are configured in ClientConfig on two levels:
+//
+// ClientConfig.CallMiddlewares are executed once per call, they wrap retries so they see the request before the first attempt
+// and the final response (or error) once retries are exhausted.
+//
+// ClientConfig.Middlewares are executed on every attempt, including retries.
+//
+// On both levels the first middleware on the list is the outermost one.
+type Middleware func(next corehttp.RoundTripper) corehttp.RoundTripper
+
+// Adapter that allows to use an ordinary function as an http.RoundTripper
+type RoundTripperFunc func(request *corehttp.Request) (*corehttp.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(request *corehttp.Request) (*corehttp.Response, error) {
+	return f(request)
+}
+
+// Wraps the RoundTripper with middlewares, the first one becomes the outermost one
+func Chain(roundTripper corehttp.RoundTripper, middlewares ...Middleware) corehttp.RoundTripper {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		roundTripper = middlewares[i](roundTripper)
+	}
+	return roundTripper
+}
+
+// Creates a Middleware setting static headers on every request
+func HeadersMiddleware(headers Headers) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			if len(headers) == 0 {
+				return next.RoundTrip(request)
+			}
+			request = request.Clone(request.Context())
+			for key, value := range headers {
+				request.Header.Set(key, value)
+			}
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// Sets headers which are not set on the request yet, so headers of Request passed to Client.Do take precedence
+func defaultHeadersMiddleware(headers Headers) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			if len(headers) == 0 {
+				return next.RoundTrip(request)
+			}
+			request = request.Clone(request.Context())
+			for key, value := range headers {
+				if len(request.Header.Get(key)) == 0 {
+					request.Header.Set(key, value)
+				}
+			}
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+type headersKey struct{}
+
+// Returns a copy of the context carrying headers which are set on the request of the call,
+// they take precedence over ClientConfig.Headers
+func WithHeaders(ctx context.Context, headers Headers) context.Context {
+	merged := Headers{}
+	for key, value := range headersFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+func headersFromContext(ctx context.Context) Headers {
+	headers, _ := ctx.Value(headersKey{}).(Headers)
+	return headers
+}
+
+type correlationIdKey struct{}
+
+// Returns a copy of the context carrying correlation id which is picked up by CorrelationIdMiddleware
+func WithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, correlationIdKey{}, correlationId)
+}
+
+// Creates a Middleware setting the header to the correlation id from the request's context (see WithCorrelationId)
+// or to a random UUID if there's none. Used as a call middleware it keeps the same id across retries.
+func CorrelationIdMiddleware(header string) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			correlationId, ok := request.Context().Value(correlationIdKey{}).(string)
+			if !ok {
+				correlationId = uuid.New().String()
+			}
+			request = request.Clone(request.Context())
+			request.Header.Set(header, correlationId)
+			return next.RoundTrip(request)
+		})
+	}
+}
+
+// Authorises every request, if the server responds with HTTP-401 and the Authenticator is refreshable
+// its credentials are invalidated and the request is repeated once.
+func authMiddleware(auth Authenticator, metrics Metrics) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			authorised, err := authorise(auth, request)
+			if err != nil {
+				return nil, err
+			}
+			response, err := next.RoundTrip(authorised)
+			if err != nil || response.StatusCode != corehttp.StatusUnauthorized {
+				return response, err
+			}
+
+			refreshable, ok := auth.(RefreshableAuthenticator)
+			if !ok {
+				return response, err
+			}
+			refreshable.Invalidate(authorised)
+			response.Body.Close()
+			if metrics != nil {
+				metrics.ObserveEvent("auth", "credentials_invalidated")
+			}
+
+			rewound, err := rewind(request)
+			if err != nil {
+				return nil, err
+			}
+			authorised, err = authorise(auth, rewound)
+			if err != nil {
+				return nil, err
+			}
+			return next.RoundTrip(authorised)
+		})
+	}
+}
+
+func authorise(auth Authenticator, request *corehttp.Request) (*corehttp.Request, error) {
+	authorised := request.Clone(request.Context())
+	if err := auth.Authenticate(authorised.Context(), authorised); err != nil {
+		return nil, &ClientError{Message: "authentication error", Class: AuthenticationErrorClass, Url: request.URL, Err: err, IsRetryable: isRetryable(err)}
+	}
+	return authorised, nil
+}
+
+func signerMiddleware(signer Signer) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			signed := request.Clone(request.Context())
+			if err := signer.Sign(signed); err != nil {
+				return nil, &ClientError{Message: "signing error", Class: SigningErrorClass, Url: request.URL, Err: err}
+			}
+			return next.RoundTrip(signed)
+		})
+	}
+}
+
+// Logs every attempt along with its execution time and the server's request id, URLs and bodies are redacted.
+// Bodies are logged on logging.Debug level.
+func loggingMiddleware(logger logging.Logger, requestIdHeader string) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			fields := []logging.Field{
+				logging.F("method", request.Method),
+				logging.F("url", logging.RedactUrl(request.URL)),
+				logging.F("attempt", AttemptFromContext(request.Context())),
+			}
+			logger.Log(logging.Info, "Outgoing request", fields...)
+			if body := peekRequestBody(request); len(body) > 0 {
+				logger.Log(logging.Debug, "Outgoing request body", append(fields, logging.F("body", logging.RedactBody(body)))...)
+			}
+
+			startTime := time.Now()
+			response, err := next.RoundTrip(request)
+			fields = append(fields, logging.F("latency", time.Now().Sub(startTime).String()))
+
+			var dryRunError *DryRunError
+			if errors.As(err, &dryRunError) {
+				fields = append(fields, logging.F("headers", redactHeaders(dryRunError.Header)))
+				if len(dryRunError.Body) > 0 {
+					fields = append(fields, logging.F("body", logging.RedactBody(dryRunError.Body)))
+				}
+				logger.Log(logging.Info, "Outgoing request skipped in dry run", fields...)
+				return response, err
+			}
+			if err != nil {
+				logger.Log(logging.Warn, "Outgoing request failed", append(fields, logging.F("error", logging.Redact(err.Error())))...)
+				return response, err
+			}
+
+			fields = append(fields, logging.F("status", response.StatusCode))
+			if requestId := response.Header.Get(requestIdHeader); len(requestId) > 0 {
+				fields = append(fields, logging.F("request_id", requestId))
+			}
+			if response.StatusCode >= 400 {
+				logger.Log(logging.Warn, "Outgoing request failed", fields...)
+			} else {
+				logger.Log(logging.Info, "Outgoing request completed", fields...)
+			}
+			if body := peekResponseBody(response); len(body) > 0 {
+				logger.Log(logging.Debug, "Incoming response body", append(fields, logging.F("body", logging.RedactBody(body)))...)
+			}
+			return response, err
+		})
+	}
+}
+
+func peekRequestBody(request *corehttp.Request) []byte {
+	if request.GetBody == nil {
+		return nil
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	buffer, _ := ioutil.ReadAll(body)
+	return buffer
+}
+
+// Reads the body and replaces it with an in-memory copy
+func peekResponseBody(response *corehttp.Response) []byte {
+	if response.Body == nil {
+		return nil
+	}
+	buffer, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(buffer))
+	if err != nil {
+		return nil
+	}
+	return buffer
+}
+
+type attemptKey struct{}
+
+// Returns the number of the attempt (starting from 1) which the request's context belongs to,
+// it's available to ClientConfig.Middlewares, 0 is returned outside of retries.
+func AttemptFromContext(ctx context.Context) int {
+	attempt, _ := ctx.Value(attemptKey{}).(int)
+	return attempt
+}
+
+func withAttempt(request *corehttp.Request, attempt int) *corehttp.Request {
+	return request.WithContext(context.WithValue(request.Context(), attemptKey{}, attempt))
+}
+
+// Returns a copy of the request with a fresh body, so it can be sent once again
+func rewind(request *corehttp.Request) (*corehttp.Request, error) {
+	rewound := request.Clone(request.Context())
+	if request.GetBody == nil {
+		return rewound, nil
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, &ClientError{Message: "body parse error", Class: BodyErrorClass, Url: request.URL, Err: err}
+	}
+	rewound.Body = body
+	return rewound, nil
+}
+
+// Makes sure the request can be rewound, bodies set by call middlewares without GetBody are read into memory
+func rewindable(request *corehttp.Request) (*corehttp.Request, error) {
+	if request.Body == nil || request.Body == corehttp.NoBody || request.GetBody != nil {
+		return request, nil
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, &ClientError{Message: "body parse error", Class: BodyErrorClass, Url: request.URL, Err: err}
+	}
+	request = request.Clone(request.Context())
+	request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	request.Body, _ = request.GetBody()
+	return request, nil
+}
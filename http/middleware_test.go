@@ -0,0 +1,156 @@
+package http
+
+import (
+	"accountapi-client/logging"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	t.Logf("Given three middlewares recording their order")
+	var order []string
+	recording := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+				order = append(order, name+" before")
+				response, err := next.RoundTrip(request)
+				order = append(order, name+" after")
+				return response, err
+			})
+		}
+	}
+	roundTripper := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		order = append(order, "transport")
+		return &http.Response{StatusCode: 200}, nil
+	})
+
+	t.Logf("When chaining them")
+	request, _ := http.NewRequest("GET", "http://localhost", nil)
+	Chain(roundTripper, recording("first"), recording("second"), recording("third")).RoundTrip(request)
+
+	t.Logf("Should execute the first one as the outermost")
+	assert.Equal(t, []string{
+		"first before", "second before", "third before",
+		"transport",
+		"third after", "second after", "first after",
+	}, order)
+}
+
+func TestClient_GetWithMiddlewares(t *testing.T) {
+	t.Logf("Given HTTP server returning 503 status")
+	callCount := make(map[string]int)
+	var correlationIds []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		correlationIds = append(correlationIds, req.Header.Get("X-Correlation-Id"))
+		requestHandler(503, &callCount)(res, req)
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with counting attempt and call middlewares")
+	var attempts, calls int
+	var finalStatusCode int
+	config := validClientConfig
+	config.Middlewares = []Middleware{counting(&attempts, nil)}
+	config.CallMiddlewares = []Middleware{CorrelationIdMiddleware("X-Correlation-Id"), counting(&calls, &finalStatusCode)}
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	var dummyResponse DummyResponse
+	err := client.Get(WithCorrelationId(context.Background(), "c0ffee"), serverUrl, &dummyResponse)
+
+	t.Logf("Should run attempt middleware on every retry and call middleware once")
+	assert.EqualError(t, err, (&ClientHttpError{Url: serverUrl, StatusCode: 503}).Error())
+	assert.Equal(t, 4, callCount["/"])
+	assert.Equal(t, 4, attempts)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 503, finalStatusCode)
+	assert.Equal(t, []string{"c0ffee", "c0ffee", "c0ffee", "c0ffee"}, correlationIds)
+}
+
+func TestClient_PostWithCallMiddlewareReplacingBody(t *testing.T) {
+	t.Logf("Given HTTP server returning 500 status")
+	var titles []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		titles = append(titles, string(body))
+		res.WriteHeader(500)
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client retrying all methods with call middleware replacing the body")
+	config := validClientConfig
+	config.RetryPolicy = RetryAllMethodsPolicy
+	config.CallMiddlewares = []Middleware{func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			replaced, _ := http.NewRequestWithContext(request.Context(), request.Method, request.URL.String(), nil)
+			replaced.Body = ioutil.NopCloser(strings.NewReader(`{"title":"Replaced"}`))
+			replaced.ContentLength = int64(len(`{"title":"Replaced"}`))
+			return next.RoundTrip(replaced)
+		})
+	}}
+	client, _ := NewClient(config)
+
+	t.Logf("When calling POST")
+	err := client.Post(context.Background(), serverUrl, &DummyRequest{Title: "Jan"}, nil)
+
+	t.Logf("Should send replaced body on every attempt")
+	assert.Error(t, err)
+	assert.Equal(t, []string{`{"title":"Replaced"}`, `{"title":"Replaced"}`, `{"title":"Replaced"}`, `{"title":"Replaced"}`}, titles)
+}
+
+func TestClient_PostWithLogger(t *testing.T) {
+	t.Logf("Given HTTP server returning 200 status")
+	callCount := make(map[string]int)
+	server := httptest.NewServer(requestHandlerWithBody(200, &callCount, map[string]string{"iban": "PL87109024022347282157329766"}))
+	serverUrl, _ := url.Parse(server.URL + "?account_number=112233")
+	defer server.Close()
+
+	t.Logf("And given Client with recording logger")
+	var records []string
+	config := validClientConfig
+	config.Logger = logging.LoggerFunc(func(level logging.Level, message string, fields ...logging.Field) {
+		var keys []string
+		for _, field := range fields {
+			if field.Key != "latency" {
+				keys = append(keys, fmt.Sprintf("%s=%v", field.Key, field.Value))
+			}
+		}
+		records = append(records, fmt.Sprintf("%s %s %s", level, message, strings.Join(keys, " ")))
+	})
+	client, _ := NewClient(config)
+
+	t.Logf("When calling POST")
+	err := client.Post(context.Background(), serverUrl, map[string]string{"name": "Jan"}, nil)
+
+	t.Logf("Should log structured records with redacted URL and bodies")
+	redactedUrl := server.URL + "?account_number=%5BREDACTED%5D"
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"INFO Outgoing request method=POST url=" + redactedUrl + " attempt=1",
+		`DEBUG Outgoing request body method=POST url=` + redactedUrl + ` attempt=1 body={"name":"[REDACTED]"}`,
+		"INFO Outgoing request completed method=POST url=" + redactedUrl + " attempt=1 status=200",
+		`DEBUG Incoming response body method=POST url=` + redactedUrl + ` attempt=1 status=200 body={"iban":"[REDACTED]"}`,
+	}, records)
+}
+
+func counting(count *int, statusCode *int) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+			*count++
+			response, err := next.RoundTrip(request)
+			if statusCode != nil && response != nil {
+				*statusCode = response.StatusCode
+			}
+			return response, err
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	corehttp "net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default value of OAuth2Config.ExpiryDelta
+const DefaultExpiryDelta = 30 * time.Second
+
+type OAuth2Config struct {
+	TokenUrl     *url.URL
+	ClientId     string
+	ClientSecret string
+	Scopes       []string
+	Timeout      time.Duration
+	ExpiryDelta  time.Duration
+}
+
+// Authenticator implementing OAuth2 client credentials grant (https://tools.ietf.org/html/rfc6749#section-4.4).
+//
+// Tokens are cached until shortly before they expire, only one refresh is running at a time,
+// concurrent callers wait for its result instead of requesting tokens on their own.
+type OAuth2ClientCredentials struct {
+	config  OAuth2Config
+	client  *corehttp.Client
+	now     func() time.Time
+	mutex   sync.Mutex
+	token   *oauth2Token
+	refresh *tokenRefresh
+}
+
+type oauth2Token struct {
+	accessToken string
+	tokenType   string
+	issuedAt    time.Time
+	expiresAt   time.Time
+}
+
+type tokenRefresh struct {
+	done  chan struct{}
+	token *oauth2Token
+	err   error
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Creates new instance of OAuth2ClientCredentials.
+//
+// If OAuth2Config.TokenUrl is nil it returns TokenUrlEmptyError.
+//
+// If OAuth2Config.ClientId is empty it returns ClientIdEmptyError.
+//
+// If OAuth2Config.Timeout is zero or bellow it returns TimeoutZeroError.
+//
+// If OAuth2Config.ExpiryDelta is zero, DefaultExpiryDelta is used, tokens are refreshed once they are closer to the expiry than that
+// (or than half of their lifetime for short-lived tokens).
+func NewOAuth2ClientCredentials(config OAuth2Config) (*OAuth2ClientCredentials, error) {
+	if config.TokenUrl == nil {
+		return nil, TokenUrlEmptyError
+	}
+	if len(config.ClientId) == 0 {
+		return nil, ClientIdEmptyError
+	}
+	if config.Timeout.Milliseconds() <= 0 {
+		return nil, TimeoutZeroError
+	}
+	if config.ExpiryDelta == 0 {
+		config.ExpiryDelta = DefaultExpiryDelta
+	}
+
+	return &OAuth2ClientCredentials{
+		config: config,
+		client: &corehttp.Client{Timeout: config.Timeout},
+		now:    time.Now,
+	}, nil
+}
+
+// Sets `Authorization` header with a cached or freshly obtained access token
+func (o *OAuth2ClientCredentials) Authenticate(ctx context.Context, request *corehttp.Request) error {
+	token, err := o.currentToken(ctx)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", token.header())
+	return nil
+}
+
+// Drops the cached token if it's the one that was used by the rejected request,
+// tokens refreshed by other goroutines in the meantime are kept.
+func (o *OAuth2ClientCredentials) Invalidate(request *corehttp.Request) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.token != nil && o.token.header() == request.Header.Get("Authorization") {
+		o.token = nil
+	}
+}
+
+// Returns a valid access token, requesting a new one from the token endpoint if needed.
+//
+// In case of network, parsing or io error it will return ClientError.
+//
+// In case of an http related error (>400 status code) it will return ClientHttpError along with returned status code.
+func (o *OAuth2ClientCredentials) Token(ctx context.Context) (string, error) {
+	token, err := o.currentToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	return token.accessToken, nil
+}
+
+func (o *OAuth2ClientCredentials) currentToken(ctx context.Context) (*oauth2Token, error) {
+	o.mutex.Lock()
+	if o.token != nil && o.isValid(o.token) {
+		token := o.token
+		o.mutex.Unlock()
+		return token, nil
+	}
+
+	refresh := o.refresh
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		o.refresh = refresh
+		go o.runRefresh(refresh)
+	}
+	o.mutex.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Runs detached from the caller's context as other callers might be waiting for the same refresh,
+// it's bounded by OAuth2Config.Timeout instead.
+func (o *OAuth2ClientCredentials) runRefresh(refresh *tokenRefresh) {
+	refresh.token, refresh.err = o.requestToken()
+
+	o.mutex.Lock()
+	if refresh.err == nil {
+		o.token = refresh.token
+	}
+	o.refresh = nil
+	o.mutex.Unlock()
+	close(refresh.done)
+}
+
+func (o *OAuth2ClientCredentials) requestToken() (*oauth2Token, error) {
+	tokenUrl := o.config.TokenUrl
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.config.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.config.Scopes, " "))
+	}
+
+	request, err := corehttp.NewRequest("POST", tokenUrl.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, &ClientError{Message: "network error", Class: NetworkErrorClass, Url: tokenUrl, Err: err}
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.SetBasicAuth(url.QueryEscape(o.config.ClientId), url.QueryEscape(o.config.ClientSecret))
+
+	issuedAt := o.now()
+	response, err := o.client.Do(request)
+	if err != nil {
+		return nil, &ClientError{Message: "network error", Class: NetworkErrorClass, Url: tokenUrl, Err: err, IsRetryable: true}
+	}
+	defer response.Body.Close()
+
+	buffer, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, &ClientError{Message: "io error", Class: IoErrorClass, Url: tokenUrl, Err: err}
+	}
+	if response.StatusCode >= 400 {
+		return nil, &ClientHttpError{Url: tokenUrl, StatusCode: response.StatusCode, ResponseBody: buffer, IsRetryable: response.StatusCode >= 500}
+	}
+
+	var parsed tokenResponse
+	if err = json.Unmarshal(buffer, &parsed); err != nil {
+		return nil, &ClientError{Message: "parsing error", Class: ParsingErrorClass, Url: tokenUrl, Err: err}
+	}
+	if len(parsed.AccessToken) == 0 {
+		return nil, &ClientError{Message: "parsing error", Class: ParsingErrorClass, Url: tokenUrl, Err: MissingAccessTokenError}
+	}
+
+	token := &oauth2Token{accessToken: parsed.AccessToken, tokenType: parsed.TokenType, issuedAt: issuedAt}
+	if parsed.ExpiresIn > 0 {
+		token.expiresAt = issuedAt.Add(time.Duration(parsed.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// Tokens without expires_in are valid until the server rejects them.
+// ExpiryDelta is limited to half of the token's lifetime, so short-lived tokens are not refreshed on every request.
+func (o *OAuth2ClientCredentials) isValid(token *oauth2Token) bool {
+	if token.expiresAt.IsZero() {
+		return true
+	}
+	delta := o.config.ExpiryDelta
+	if lifetime := token.expiresAt.Sub(token.issuedAt); delta > lifetime/2 {
+		delta = lifetime / 2
+	}
+	return o.now().Add(delta).Before(token.expiresAt)
+}
+
+func (t *oauth2Token) header() string {
+	if len(t.tokenType) == 0 || strings.EqualFold(t.tokenType, "bearer") {
+		return "Bearer " + t.accessToken
+	}
+	return t.tokenType + " " + t.accessToken
+}
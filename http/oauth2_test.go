@@ -0,0 +1,283 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewOAuth2ClientCredentialsWithInValidConfig(t *testing.T) {
+	tokenUrl, _ := url.Parse("http://localhost/oauth2/token")
+	testCases := []struct {
+		Config        OAuth2Config
+		ExpectedError error
+	}{
+		{Config: OAuth2Config{ClientId: "id", Timeout: time.Second}, ExpectedError: TokenUrlEmptyError},
+		{Config: OAuth2Config{TokenUrl: tokenUrl, Timeout: time.Second}, ExpectedError: ClientIdEmptyError},
+		{Config: OAuth2Config{TokenUrl: tokenUrl, ClientId: "id"}, ExpectedError: TimeoutZeroError},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given invalid OAuth2Config %+v", testCase.Config)
+
+		t.Logf("When creating OAuth2ClientCredentials")
+		auth, err := NewOAuth2ClientCredentials(testCase.Config)
+
+		t.Logf("Should return '%s' error", testCase.ExpectedError)
+		assert.EqualError(t, err, testCase.ExpectedError.Error())
+		assert.Nil(t, auth)
+	}
+}
+
+func TestOAuth2ClientCredentials_TokenIsCached(t *testing.T) {
+	t.Logf("Given token server issuing tokens valid for an hour")
+	tokenServer, tokenCalls := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	t.Logf("And given OAuth2ClientCredentials")
+	auth := newOAuth2(tokenServer.URL)
+
+	t.Logf("When asking for a token three times")
+	var tokens []string
+	for range makeRange(3) {
+		token, err := auth.Token(context.Background())
+		assert.NoError(t, err)
+		tokens = append(tokens, token)
+	}
+
+	t.Logf("Should call token server once and return the same token")
+	assert.Equal(t, int32(1), atomic.LoadInt32(tokenCalls))
+	assert.Equal(t, []string{"token-1", "token-1", "token-1"}, tokens)
+}
+
+func TestOAuth2ClientCredentials_TokenIsRefreshedBeforeExpiry(t *testing.T) {
+	t.Logf("Given token server issuing tokens valid for a minute")
+	tokenServer, tokenCalls := newTokenServer(t, 60)
+	defer tokenServer.Close()
+
+	t.Logf("And given OAuth2ClientCredentials with a controlled clock")
+	auth := newOAuth2(tokenServer.URL)
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	t.Logf("When asking for a token before and after it gets stale")
+	first, _ := auth.Token(context.Background())
+	now = now.Add(20 * time.Second)
+	second, _ := auth.Token(context.Background())
+	now = now.Add(20 * time.Second)
+	third, _ := auth.Token(context.Background())
+
+	t.Logf("Should refresh the token once it's within expiry delta")
+	assert.Equal(t, "token-1", first)
+	assert.Equal(t, "token-1", second)
+	assert.Equal(t, "token-2", third)
+	assert.Equal(t, int32(2), atomic.LoadInt32(tokenCalls))
+}
+
+func TestOAuth2ClientCredentials_ShortLivedTokenIsCached(t *testing.T) {
+	t.Logf("Given token server issuing tokens valid for 20 seconds, less than the expiry delta")
+	tokenServer, tokenCalls := newTokenServer(t, 20)
+	defer tokenServer.Close()
+
+	t.Logf("And given OAuth2ClientCredentials with a controlled clock")
+	auth := newOAuth2(tokenServer.URL)
+	now := time.Now()
+	auth.now = func() time.Time { return now }
+
+	t.Logf("When asking for a token within and after half of its lifetime")
+	first, _ := auth.Token(context.Background())
+	now = now.Add(5 * time.Second)
+	second, _ := auth.Token(context.Background())
+	now = now.Add(6 * time.Second)
+	third, _ := auth.Token(context.Background())
+
+	t.Logf("Should reuse the token until half of its lifetime passes")
+	assert.Equal(t, "token-1", first)
+	assert.Equal(t, "token-1", second)
+	assert.Equal(t, "token-2", third)
+	assert.Equal(t, int32(2), atomic.LoadInt32(tokenCalls))
+}
+
+func TestOAuth2ClientCredentials_ConcurrentRefreshIsShared(t *testing.T) {
+	t.Logf("Given slow token server")
+	var tokenCalls int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		call := atomic.AddInt32(&tokenCalls, 1)
+		time.Sleep(50 * time.Millisecond)
+		writeToken(res, fmt.Sprintf("token-%d", call), 3600)
+	}))
+	defer tokenServer.Close()
+
+	t.Logf("And given OAuth2ClientCredentials")
+	auth := newOAuth2(tokenServer.URL)
+
+	t.Logf("When asking for a token from 10 goroutines")
+	var group sync.WaitGroup
+	for range makeRange(10) {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			token, err := auth.Token(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "token-1", token)
+		}()
+	}
+	group.Wait()
+
+	t.Logf("Should call token server once")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tokenCalls))
+}
+
+func TestOAuth2ClientCredentials_TokenServerError(t *testing.T) {
+	t.Logf("Given token server rejecting credentials")
+	callCount := make(map[string]int)
+	tokenServer := httptest.NewServer(requestHandler(401, &callCount))
+	defer tokenServer.Close()
+
+	t.Logf("And given OAuth2ClientCredentials")
+	auth := newOAuth2(tokenServer.URL)
+
+	t.Logf("When asking for a token")
+	token, err := auth.Token(context.Background())
+
+	t.Logf("Should return ClientHttpError with statusCode 401")
+	tokenUrl, _ := url.Parse(tokenServer.URL)
+	assert.EqualError(t, err, (&ClientHttpError{Url: tokenUrl, StatusCode: 401}).Error())
+	assert.Empty(t, token)
+}
+
+func TestClient_GetWithOAuth2(t *testing.T) {
+	t.Logf("Given token server")
+	tokenServer, _ := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	t.Logf("And HTTP server accepting only bearer tokens")
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		authorization = req.Header.Get("Authorization")
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with OAuth2ClientCredentials")
+	config := validClientConfig
+	config.Auth = newOAuth2(tokenServer.URL)
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	var dummyResponse DummyResponse
+	err := client.Get(context.Background(), serverUrl, &dummyResponse)
+
+	t.Logf("Should send bearer token")
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token-1", authorization)
+	assert.Equal(t, DummyResponse{Title: "Jan", Id: 1}, dummyResponse)
+}
+
+func TestClient_PostWithExpiredOAuth2Token(t *testing.T) {
+	t.Logf("Given token server")
+	tokenServer, tokenCalls := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	t.Logf("And HTTP server rejecting the first token")
+	var authorizations []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var request DummyRequest
+		json.NewDecoder(req.Body).Decode(&request)
+		authorizations = append(authorizations, req.Header.Get("Authorization"))
+		bodies = append(bodies, request.Title)
+		if req.Header.Get("Authorization") == "Bearer token-1" {
+			res.WriteHeader(401)
+			return
+		}
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with OAuth2ClientCredentials")
+	config := validClientConfig
+	config.Auth = newOAuth2(tokenServer.URL)
+	client, _ := NewClient(config)
+
+	t.Logf("When calling POST")
+	var dummyResponse DummyResponse
+	err := client.Post(context.Background(), serverUrl, &DummyRequest{Title: "Jan"}, &dummyResponse)
+
+	t.Logf("Should repeat the request once with a fresh token and the same body")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, authorizations)
+	assert.Equal(t, []string{"Jan", "Jan"}, bodies)
+	assert.Equal(t, int32(2), atomic.LoadInt32(tokenCalls))
+}
+
+func TestClient_GetWithRejectedOAuth2Token(t *testing.T) {
+	t.Logf("Given token server")
+	tokenServer, tokenCalls := newTokenServer(t, 3600)
+	defer tokenServer.Close()
+
+	t.Logf("And HTTP server rejecting all tokens")
+	callCount := make(map[string]int)
+	server := httptest.NewServer(requestHandler(401, &callCount))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with OAuth2ClientCredentials")
+	config := validClientConfig
+	config.Auth = newOAuth2(tokenServer.URL)
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	var dummyResponse DummyResponse
+	err := client.Get(context.Background(), serverUrl, &dummyResponse)
+
+	t.Logf("Should refresh the token only once and return ClientHttpError with statusCode 401")
+	assert.EqualError(t, err, (&ClientHttpError{Url: serverUrl, StatusCode: 401}).Error())
+	assert.Equal(t, 2, callCount["/"])
+	assert.Equal(t, int32(2), atomic.LoadInt32(tokenCalls))
+}
+
+func newTokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	var tokenCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		clientId, clientSecret, _ := req.BasicAuth()
+		assert.Equal(t, "client", clientId)
+		assert.Equal(t, "secret", clientSecret)
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "client_credentials", req.PostForm.Get("grant_type"))
+		call := atomic.AddInt32(&tokenCalls, 1)
+		writeToken(res, fmt.Sprintf("token-%d", call), expiresIn)
+	}))
+	return server, &tokenCalls
+}
+
+func writeToken(res http.ResponseWriter, token string, expiresIn int) {
+	js, _ := json.Marshal(tokenResponse{AccessToken: token, TokenType: "bearer", ExpiresIn: expiresIn})
+	res.Header().Set("Content-Type", "application/json")
+	res.Write(js)
+}
+
+func newOAuth2(tokenUrl string) *OAuth2ClientCredentials {
+	parsed, _ := url.Parse(tokenUrl)
+	auth, _ := NewOAuth2ClientCredentials(OAuth2Config{
+		TokenUrl:     parsed,
+		ClientId:     "client",
+		ClientSecret: "secret",
+		Timeout:      time.Second,
+	})
+	return auth
+}
+
+func makeRange(max int) []int {
+	return make([]int, max)
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	corehttp "net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default histogram buckets (in seconds) of PrometheusMetrics
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics implementation which keeps measurements in memory and exposes them in Prometheus text format
+// (https://prometheus.io/docs/instrumenting/exposition_formats/) when served as an http.Handler:
+//
+//	<namespace>_http_client_requests_total{operation, method, status_class}
+//	<namespace>_http_client_request_duration_seconds{operation, method} (histogram)
+//	<namespace>_http_client_retries_total{operation, method}
+//	<namespace>_http_client_events_total{component, event}
+//
+// It also measures operations of services built on top of the Client (see ObserveOperation) in separate metrics:
+//
+//	<namespace>_service_operations_total{operation, outcome}
+//	<namespace>_service_operation_duration_seconds{operation} (histogram)
+type PrometheusMetrics struct {
+	namespace          string
+	buckets            []float64
+	mutex              sync.Mutex
+	requests           map[string]float64
+	latencies          map[string]*histogram
+	retries            map[string]float64
+	events             map[string]float64
+	operations         map[string]float64
+	operationLatencies map[string]*histogram
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Creates new instance of PrometheusMetrics, metric names are prefixed with the namespace (if not empty).
+//
+// If buckets are empty, DefaultLatencyBuckets are used.
+func NewPrometheusMetrics(namespace string, buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	return &PrometheusMetrics{
+		namespace:          namespace,
+		buckets:            sorted,
+		requests:           make(map[string]float64),
+		latencies:          make(map[string]*histogram),
+		retries:            make(map[string]float64),
+		events:             make(map[string]float64),
+		operations:         make(map[string]float64),
+		operationLatencies: make(map[string]*histogram),
+	}
+}
+
+func (p *PrometheusMetrics) ObserveRequest(operation string, method string, statusClass string, latency time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.requests[labels("operation", operation, "method", method, "status_class", statusClass)]++
+	p.observeLatency(p.latencies, labels("operation", operation, "method", method), latency)
+}
+
+// Measures an operation of a service, e.g. account.MetricsDecorator, the outcome is defined by the service
+func (p *PrometheusMetrics) ObserveOperation(operation string, outcome string, latency time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.operations[labels("operation", operation, "outcome", outcome)]++
+	p.observeLatency(p.operationLatencies, labels("operation", operation), latency)
+}
+
+// Must be called with the mutex held
+func (p *PrometheusMetrics) observeLatency(histograms map[string]*histogram, key string, latency time.Duration) {
+	latencies, ok := histograms[key]
+	if !ok {
+		latencies = &histogram{counts: make([]uint64, len(p.buckets))}
+		histograms[key] = latencies
+	}
+	seconds := latency.Seconds()
+	for i, bucket := range p.buckets {
+		if seconds <= bucket {
+			latencies.counts[i]++
+		}
+	}
+	latencies.sum += seconds
+	latencies.count++
+}
+
+func (p *PrometheusMetrics) ObserveRetry(operation string, method string, attempt int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.retries[labels("operation", operation, "method", method)]++
+}
+
+func (p *PrometheusMetrics) ObserveEvent(component string, event string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.events[labels("component", component, "event", event)]++
+}
+
+// Serves all the metrics in Prometheus text format
+func (p *PrometheusMetrics) ServeHTTP(response corehttp.ResponseWriter, request *corehttp.Request) {
+	response.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	response.Write([]byte(p.String()))
+}
+
+// Returns all the metrics in Prometheus text format
+func (p *PrometheusMetrics) String() string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	var buffer bytes.Buffer
+	writeCounter(&buffer, p.name("http_client_requests_total"), "Number of http calls by their outcome.", p.requests)
+	p.writeHistogram(&buffer, p.name("http_client_request_duration_seconds"), "Latency of http calls including retries.", p.latencies)
+	writeCounter(&buffer, p.name("http_client_retries_total"), "Number of retried http attempts.", p.retries)
+	writeCounter(&buffer, p.name("http_client_events_total"), "Number of resilience events.", p.events)
+	writeCounter(&buffer, p.name("service_operations_total"), "Number of service operations by their outcome.", p.operations)
+	p.writeHistogram(&buffer, p.name("service_operation_duration_seconds"), "Latency of service operations.", p.operationLatencies)
+	return buffer.String()
+}
+
+func (p *PrometheusMetrics) writeHistogram(buffer *bytes.Buffer, name string, help string, histograms map[string]*histogram) {
+	fmt.Fprintf(buffer, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, key := range sortedKeys(histograms) {
+		latencies := histograms[key]
+		for i, bucket := range p.buckets {
+			fmt.Fprintf(buffer, "%s_bucket{%s,le=\"%s\"} %d\n", name, key, formatFloat(bucket), latencies.counts[i])
+		}
+		fmt.Fprintf(buffer, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, key, latencies.count)
+		fmt.Fprintf(buffer, "%s_sum{%s} %s\n", name, key, formatFloat(latencies.sum))
+		fmt.Fprintf(buffer, "%s_count{%s} %d\n", name, key, latencies.count)
+	}
+}
+
+func (p *PrometheusMetrics) name(name string) string {
+	if len(p.namespace) == 0 {
+		return name
+	}
+	return p.namespace + "_" + name
+}
+
+func writeCounter(buffer *bytes.Buffer, name string, help string, values map[string]float64) {
+	fmt.Fprintf(buffer, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(buffer, "%s{%s} %s\n", name, key, formatFloat(values[key]))
+	}
+}
+
+func sortedKeys(values map[string]*histogram) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Formats label pairs as `name="value",name="value"` with escaped values
+func labels(pairs ...string) string {
+	formatted := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		formatted = append(formatted, fmt.Sprintf(`%s="%s"`, pairs[i], labelEscaper.Replace(pairs[i+1])))
+	}
+	return strings.Join(formatted, ",")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusMetrics_String(t *testing.T) {
+	t.Logf("Given PrometheusMetrics with two buckets")
+	metrics := NewPrometheusMetrics("accountapi", 0.1, 1)
+
+	t.Logf("When observing requests, retries, events and service operations")
+	metrics.ObserveRequest("account.fetch", "GET", "2xx", 50*time.Millisecond)
+	metrics.ObserveRequest("account.fetch", "GET", "5xx", 500*time.Millisecond)
+	metrics.ObserveRetry("account.fetch", "GET", 2)
+	metrics.ObserveEvent("auth", "credentials_invalidated")
+	metrics.ObserveOperation("account.fetch", "validation", 20*time.Millisecond)
+
+	t.Logf("Should expose them in text format")
+	assert.Equal(t, `# HELP accountapi_http_client_requests_total Number of http calls by their outcome.
+# TYPE accountapi_http_client_requests_total counter
+accountapi_http_client_requests_total{operation="account.fetch",method="GET",status_class="2xx"} 1
+accountapi_http_client_requests_total{operation="account.fetch",method="GET",status_class="5xx"} 1
+# HELP accountapi_http_client_request_duration_seconds Latency of http calls including retries.
+# TYPE accountapi_http_client_request_duration_seconds histogram
+accountapi_http_client_request_duration_seconds_bucket{operation="account.fetch",method="GET",le="0.1"} 1
+accountapi_http_client_request_duration_seconds_bucket{operation="account.fetch",method="GET",le="1"} 2
+accountapi_http_client_request_duration_seconds_bucket{operation="account.fetch",method="GET",le="+Inf"} 2
+accountapi_http_client_request_duration_seconds_sum{operation="account.fetch",method="GET"} 0.55
+accountapi_http_client_request_duration_seconds_count{operation="account.fetch",method="GET"} 2
+# HELP accountapi_http_client_retries_total Number of retried http attempts.
+# TYPE accountapi_http_client_retries_total counter
+accountapi_http_client_retries_total{operation="account.fetch",method="GET"} 1
+# HELP accountapi_http_client_events_total Number of resilience events.
+# TYPE accountapi_http_client_events_total counter
+accountapi_http_client_events_total{component="auth",event="credentials_invalidated"} 1
+# HELP accountapi_service_operations_total Number of service operations by their outcome.
+# TYPE accountapi_service_operations_total counter
+accountapi_service_operations_total{operation="account.fetch",outcome="validation"} 1
+# HELP accountapi_service_operation_duration_seconds Latency of service operations.
+# TYPE accountapi_service_operation_duration_seconds histogram
+accountapi_service_operation_duration_seconds_bucket{operation="account.fetch",le="0.1"} 1
+accountapi_service_operation_duration_seconds_bucket{operation="account.fetch",le="1"} 1
+accountapi_service_operation_duration_seconds_bucket{operation="account.fetch",le="+Inf"} 1
+accountapi_service_operation_duration_seconds_sum{operation="account.fetch"} 0.02
+accountapi_service_operation_duration_seconds_count{operation="account.fetch"} 1
+`, metrics.String())
+}
+
+func TestClient_GetWithMetrics(t *testing.T) {
+	t.Logf("Given HTTP server returning 500 status")
+	callCount := make(map[string]int)
+	server := httptest.NewServer(requestHandler(500, &callCount))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with PrometheusMetrics")
+	metrics := NewPrometheusMetrics("")
+	config := validClientConfig
+	config.Metrics = metrics
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	err := client.Get(WithOperation(context.Background(), "dummy.get"), serverUrl, nil)
+
+	t.Logf("Should count one failed call with three retries")
+	assert.Error(t, err)
+	exposed := metrics.String()
+	assert.Contains(t, exposed, `http_client_requests_total{operation="dummy.get",method="GET",status_class="5xx"} 1`)
+	assert.Contains(t, exposed, `http_client_request_duration_seconds_count{operation="dummy.get",method="GET"} 1`)
+	assert.Contains(t, exposed, `http_client_retries_total{operation="dummy.get",method="GET"} 3`)
+
+	t.Logf("And should serve them over HTTP")
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain"))
+	assert.Equal(t, exposed, string(body))
+}
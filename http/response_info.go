@@ -0,0 +1,66 @@
+package http
+
+import (
+	"accountapi-client/retry"
+	"context"
+	corehttp "net/http"
+	"net/url"
+	"time"
+)
+
+// Default value of ClientConfig.RequestIdHeader
+const DefaultRequestIdHeader = "X-Request-Id"
+
+// Metadata of a finished call, it's filled for failed calls as well (StatusCode is 0 if no response was received)
+type ResponseInfo struct {
+	StatusCode int
+	Header     corehttp.Header
+	// Value of ClientConfig.RequestIdHeader returned by the server, worth attaching to support tickets
+	RequestId string
+	// URL of the last attempt
+	Url *url.URL
+	// Base URL which served the last attempt when Failover is configured, nil otherwise
+	Endpoint *url.URL
+	Attempts int
+	// Total time of the call including retries
+	Elapsed time.Duration
+}
+
+type responseInfoKey struct{}
+
+// Returns a copy of the context which makes the Client fill info once the call is finished:
+//
+//	var info http.ResponseInfo
+//	err := client.Get(http.WithResponseInfo(ctx, &info), url, &response)
+//	log.Printf("request id %s after %d attempts", info.RequestId, info.Attempts)
+func WithResponseInfo(ctx context.Context, info *ResponseInfo) context.Context {
+	return context.WithValue(ctx, responseInfoKey{}, info)
+}
+
+func responseInfoFromContext(ctx context.Context) *ResponseInfo {
+	info, _ := ctx.Value(responseInfoKey{}).(*ResponseInfo)
+	return info
+}
+
+// Progress of the call shared between the Client and its retries
+type callState struct {
+	retries  *retry.Retry
+	attempts int
+	url      *url.URL
+	endpoint *url.URL
+}
+
+type callStateKey struct{}
+
+func withCallState(request *corehttp.Request) (*corehttp.Request, *callState) {
+	state := &callState{url: request.URL}
+	return request.WithContext(context.WithValue(request.Context(), callStateKey{}, state)), state
+}
+
+func callStateFromContext(ctx context.Context) *callState {
+	state, ok := ctx.Value(callStateKey{}).(*callState)
+	if !ok {
+		return &callState{}
+	}
+	return state
+}
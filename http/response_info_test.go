@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestClient_GetWithResponseInfo(t *testing.T) {
+	t.Logf("Given HTTP server failing the first call")
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.Header().Set("X-Request-Id", "req-"+strconv.Itoa(calls))
+		if calls == 1 {
+			res.WriteHeader(503)
+			return
+		}
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling GET with ResponseInfo")
+	var info ResponseInfo
+	var dummyResponse DummyResponse
+	err := client.Get(WithResponseInfo(context.Background(), &info), serverUrl, &dummyResponse)
+
+	t.Logf("Should fill it with metadata of the last attempt")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, info.StatusCode)
+	assert.Equal(t, "req-2", info.RequestId)
+	assert.Equal(t, "req-2", info.Header.Get("X-Request-Id"))
+	assert.Equal(t, 2, info.Attempts)
+	assert.Equal(t, serverUrl.String(), info.Url.String())
+	assert.True(t, info.Elapsed > 0)
+}
+
+func TestClient_DeleteWithResponseInfoAndHttpError(t *testing.T) {
+	t.Logf("Given HTTP server always failing with custom request id header")
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Trace-Id", "abc")
+		res.WriteHeader(409)
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client reading request ids from it")
+	config := validClientConfig
+	config.RequestIdHeader = "Trace-Id"
+	client, _ := NewClient(config)
+
+	t.Logf("When calling DELETE with ResponseInfo")
+	var info ResponseInfo
+	err := client.Delete(WithResponseInfo(context.Background(), &info), serverUrl)
+
+	t.Logf("Should return the error and fill the metadata")
+	var httpError *ClientHttpError
+	assert.True(t, errors.As(err, &httpError))
+	assert.Equal(t, 409, info.StatusCode)
+	assert.Equal(t, "abc", info.RequestId)
+	assert.Equal(t, 1, info.Attempts)
+}
+
+func TestClient_GetWithResponseInfoAndNetworkError(t *testing.T) {
+	t.Logf("Given closed HTTP server")
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	serverUrl, _ := url.Parse(server.URL)
+	server.Close()
+
+	t.Logf("And given Client")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling GET with ResponseInfo")
+	var info ResponseInfo
+	err := client.Get(WithResponseInfo(context.Background(), &info), serverUrl, nil)
+
+	t.Logf("Should count all the attempts without any status")
+	assert.Error(t, err)
+	assert.Equal(t, 0, info.StatusCode)
+	assert.Empty(t, info.RequestId)
+	assert.Equal(t, 4, info.Attempts)
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"accountapi-client/retry"
+	"context"
+	corehttp "net/http"
+)
+
+// Class of the error of a finished attempt, see ClientError.Class
+type ErrorClass string
+
+const (
+	NoErrorClass             ErrorClass = ""
+	NetworkErrorClass        ErrorClass = "network"
+	IoErrorClass             ErrorClass = "io"
+	ParsingErrorClass        ErrorClass = "parsing"
+	BodyErrorClass           ErrorClass = "body"
+	AuthenticationErrorClass ErrorClass = "authentication"
+	SigningErrorClass        ErrorClass = "signing"
+	UnknownErrorClass        ErrorClass = "unknown"
+)
+
+// Describes a finished attempt, StatusCode is 0 if no response was received
+type AttemptResult struct {
+	Method     string
+	StatusCode int
+	Err        error
+	ErrorClass ErrorClass
+	Attempt    int
+	// True for idempotent methods (GET, HEAD, OPTIONS, TRACE, PUT, DELETE) and requests with `Idempotency-Key` header
+	// provided by the caller (with WithIdempotencyKey or the header itself), generated keys don't count
+	Idempotent bool
+}
+
+// Decides whether a finished attempt should be retried, the number of retries is still limited by retry.RetriesConfig
+type RetryPolicy func(result AttemptResult) bool
+
+// Used when ClientConfig.RetryPolicy is not provided, it retries only idempotent requests
+// (see AttemptResult.Idempotent) on network errors, retryable authentication errors and HTTP-5xx responses.
+func DefaultRetryPolicy(result AttemptResult) bool {
+	if !result.Idempotent {
+		return false
+	}
+	return RetryAllMethodsPolicy(result)
+}
+
+// Retries network errors, retryable authentication errors and HTTP-5xx responses regardless of the method,
+// it may duplicate side effects of non-idempotent requests.
+func RetryAllMethodsPolicy(result AttemptResult) bool {
+	switch result.ErrorClass {
+	case NoErrorClass:
+		return result.StatusCode >= 500
+	case NetworkErrorClass, UnknownErrorClass:
+		return true
+	case AuthenticationErrorClass:
+		return isRetryable(result.Err)
+	default:
+		return false
+	}
+}
+
+// Never retries
+func NoRetryPolicy(result AttemptResult) bool {
+	return false
+}
+
+type retryPolicyKey struct{}
+
+// Returns a copy of the context carrying RetryPolicy which overrides ClientConfig.RetryPolicy for that call
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+func retryPolicyFromContext(ctx context.Context, fallback RetryPolicy) RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok && policy != nil {
+		return policy
+	}
+	return fallback
+}
+
+type retriesKey struct{}
+
+// Returns a copy of the context carrying retry.RetriesConfig which overrides ClientConfig.Retries for that call,
+// errors of retry.NewRetries are returned by the call
+func WithRetries(ctx context.Context, config *retry.RetriesConfig) context.Context {
+	return context.WithValue(ctx, retriesKey{}, config)
+}
+
+func retriesFromContext(ctx context.Context, fallback *retry.Retry) (*retry.Retry, error) {
+	if config, ok := ctx.Value(retriesKey{}).(*retry.RetriesConfig); ok && config != nil {
+		return retry.NewRetries(config)
+	}
+	return fallback, nil
+}
+
+func newAttemptResult(request *corehttp.Request, attempt int, response *corehttp.Response, err error) AttemptResult {
+	result := AttemptResult{
+		Method:     request.Method,
+		Err:        err,
+		Attempt:    attempt,
+		Idempotent: isIdempotent(request),
+	}
+	if response != nil {
+		result.StatusCode = response.StatusCode
+	}
+	if err != nil {
+		result.ErrorClass = UnknownErrorClass
+		if clientError, ok := err.(*ClientError); ok && len(clientError.Class) > 0 {
+			result.ErrorClass = clientError.Class
+		}
+	}
+	return result
+}
+
+func isIdempotent(request *corehttp.Request) bool {
+	switch request.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+		return true
+	default:
+		generated, _ := request.Context().Value(generatedIdempotencyKeyKey{}).(bool)
+		return len(request.Header.Get(IdempotencyKeyHeader)) > 0 && !generated
+	}
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	testCases := []struct {
+		Result   AttemptResult
+		Expected bool
+	}{
+		{Result: AttemptResult{Method: "GET", StatusCode: 200, Idempotent: true}, Expected: false},
+		{Result: AttemptResult{Method: "GET", StatusCode: 404, Idempotent: true}, Expected: false},
+		{Result: AttemptResult{Method: "GET", StatusCode: 503, Idempotent: true}, Expected: true},
+		{Result: AttemptResult{Method: "DELETE", StatusCode: 500, Idempotent: true}, Expected: true},
+		{Result: AttemptResult{Method: "GET", Err: errors.New("dial"), ErrorClass: NetworkErrorClass, Idempotent: true}, Expected: true},
+		{Result: AttemptResult{Method: "GET", Err: errors.New("read"), ErrorClass: IoErrorClass, Idempotent: true}, Expected: false},
+		{Result: AttemptResult{Method: "GET", Err: &ClientError{Message: "authentication error", Err: errors.New("401")}, ErrorClass: AuthenticationErrorClass, Idempotent: true}, Expected: false},
+		{Result: AttemptResult{Method: "GET", Err: &ClientError{Message: "authentication error", IsRetryable: true}, ErrorClass: AuthenticationErrorClass, Idempotent: true}, Expected: true},
+		{Result: AttemptResult{Method: "POST", StatusCode: 503, Idempotent: false}, Expected: false},
+		{Result: AttemptResult{Method: "POST", Err: errors.New("dial"), ErrorClass: NetworkErrorClass, Idempotent: false}, Expected: false},
+		{Result: AttemptResult{Method: "POST", StatusCode: 503, Idempotent: true}, Expected: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given attempt result %+v", testCase.Result)
+
+		t.Logf("When deciding on retry")
+		decision := DefaultRetryPolicy(testCase.Result)
+
+		t.Logf("Should return %t", testCase.Expected)
+		assert.Equal(t, testCase.Expected, decision)
+	}
+}
+
+func TestNewAttemptResultErrorClass(t *testing.T) {
+	request := httptest.NewRequest("GET", "http://localhost/", nil)
+	testCases := []struct {
+		Err      error
+		Expected ErrorClass
+	}{
+		{Err: nil, Expected: NoErrorClass},
+		{Err: &ClientError{Message: "connection refused while dialing", Class: NetworkErrorClass}, Expected: NetworkErrorClass},
+		{Err: &ClientError{Message: "network error"}, Expected: UnknownErrorClass},
+		{Err: errors.New("other"), Expected: UnknownErrorClass},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given attempt error %v", testCase.Err)
+
+		t.Logf("When creating attempt result")
+		result := newAttemptResult(request, 1, nil, testCase.Err)
+
+		t.Logf("Should take the class from the error, not from its message")
+		assert.Equal(t, testCase.Expected, result.ErrorClass)
+	}
+}
+
+func TestClient_PostWithIdempotencyKeyHeader(t *testing.T) {
+	t.Logf("Given HTTP server returning 500 status")
+	callCount := make(map[string]int)
+	server := httptest.NewServer(requestHandler(500, &callCount))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client setting Idempotency-Key header on every call")
+	config := validClientConfig
+	config.CallMiddlewares = []Middleware{HeadersMiddleware(Headers{"Idempotency-Key": "2f9c5d6e"})}
+	client, _ := NewClient(config)
+
+	t.Logf("When calling POST")
+	err := client.Post(context.Background(), serverUrl, &DummyRequest{Title: "Jan"}, nil)
+
+	t.Logf("Should retry it as it's idempotent")
+	assert.EqualError(t, err, (&ClientHttpError{Url: serverUrl, StatusCode: 500}).Error())
+	assert.Equal(t, 4, callCount["/"])
+}
+
+func TestClient_GetWithRetryPolicyOverride(t *testing.T) {
+	t.Logf("Given HTTP server returning 500 status")
+	callCount := make(map[string]int)
+	server := httptest.NewServer(requestHandler(500, &callCount))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with a policy retrying only the first attempt")
+	var results []AttemptResult
+	config := validClientConfig
+	config.RetryPolicy = func(result AttemptResult) bool {
+		results = append(results, result)
+		return result.Attempt < 2
+	}
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	err := client.Get(context.Background(), serverUrl, nil)
+
+	t.Logf("Should call the policy with attempt results and stop after the second attempt")
+	assert.Error(t, err)
+	assert.Equal(t, 2, callCount["/"])
+	assert.Equal(t, []AttemptResult{
+		{Method: "GET", StatusCode: 500, Attempt: 1, Idempotent: true},
+		{Method: "GET", StatusCode: 500, Attempt: 2, Idempotent: true},
+	}, results)
+
+	t.Logf("When calling GET with NoRetryPolicy in the context")
+	err = client.Get(WithRetryPolicy(context.Background(), NoRetryPolicy), serverUrl, nil)
+
+	t.Logf("Should not retry")
+	assert.Error(t, err)
+	assert.Equal(t, 3, callCount["/"])
+}
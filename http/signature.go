@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	corehttp "net/http"
+	"strings"
+	"time"
+)
+
+// Headers covered by the signature when HttpSignatureConfig.Headers is empty
+var DefaultSignedHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+// Signs outgoing requests, it is called by the Client before every attempt (including retries)
+// after all the other headers (including authorisation) were set.
+type Signer interface {
+	Sign(request *corehttp.Request) error
+}
+
+type HttpSignatureConfig struct {
+	KeyId      string
+	PrivateKey crypto.Signer
+	Headers    []string
+}
+
+// Signer implementing HTTP message signatures (https://tools.ietf.org/html/draft-cavage-http-signatures-12)
+// as required by Form3 (https://api-docs.form3.tech/tutorial-request-signing.html).
+//
+// On every request it sets `Date` and `Digest` (SHA-256 of the body) headers, builds the signing string
+// out of the configured headers and sets the `Signature` header signed with RSA (rsa-sha256) or Ed25519 (ed25519) key.
+type HttpSignatureSigner struct {
+	keyId      string
+	privateKey crypto.Signer
+	algorithm  string
+	headers    []string
+	now        func() time.Time
+}
+
+// Creates new instance of HttpSignatureSigner.
+//
+// If HttpSignatureConfig.KeyId is empty it returns KeyIdEmptyError.
+//
+// If HttpSignatureConfig.PrivateKey is nil it returns PrivateKeyEmptyError,
+// if it's neither RSA nor Ed25519 key it returns UnsupportedKeyError.
+//
+// If HttpSignatureConfig.Headers is empty, DefaultSignedHeaders are signed.
+func NewHttpSignatureSigner(config HttpSignatureConfig) (*HttpSignatureSigner, error) {
+	if len(config.KeyId) == 0 {
+		return nil, KeyIdEmptyError
+	}
+	if config.PrivateKey == nil {
+		return nil, PrivateKeyEmptyError
+	}
+
+	var algorithm string
+	switch config.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		algorithm = "rsa-sha256"
+	case ed25519.PrivateKey:
+		algorithm = "ed25519"
+	default:
+		return nil, UnsupportedKeyError
+	}
+
+	headers := config.Headers
+	if len(headers) == 0 {
+		headers = DefaultSignedHeaders
+	}
+	lowerCased := make([]string, len(headers))
+	for i, header := range headers {
+		lowerCased[i] = strings.ToLower(header)
+	}
+
+	return &HttpSignatureSigner{
+		keyId:      config.KeyId,
+		privateKey: config.PrivateKey,
+		algorithm:  algorithm,
+		headers:    lowerCased,
+		now:        time.Now,
+	}, nil
+}
+
+// Sets `Date`, `Digest` and `Signature` headers on the request, the body is read and replaced with an in-memory copy.
+func (s *HttpSignatureSigner) Sign(request *corehttp.Request) error {
+	body, err := readBody(request)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(body)
+	request.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(digest[:]))
+	request.Header.Set("Date", s.now().UTC().Format(corehttp.TimeFormat))
+
+	signingString, err := SigningString(request, s.headers)
+	if err != nil {
+		return err
+	}
+	signature, err := s.sign([]byte(signingString))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`,
+		s.keyId, s.algorithm, strings.Join(s.headers, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+func (s *HttpSignatureSigner) sign(signingString []byte) ([]byte, error) {
+	if s.algorithm == "ed25519" {
+		return s.privateKey.Sign(rand.Reader, signingString, crypto.Hash(0))
+	}
+	hashed := sha256.Sum256(signingString)
+	return s.privateKey.Sign(rand.Reader, hashed[:], crypto.SHA256)
+}
+
+// Builds the string which is signed by HttpSignatureSigner, one `name: value` line per header.
+//
+// It returns an error when any of the headers is missing on the request.
+func SigningString(request *corehttp.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		var value string
+		switch header {
+		case "(request-target)":
+			value = strings.ToLower(request.Method) + " " + request.URL.RequestURI()
+		case "host":
+			value = request.Host
+			if len(value) == 0 {
+				value = request.URL.Host
+			}
+		default:
+			values := request.Header.Values(header)
+			if len(values) == 0 {
+				return "", fmt.Errorf("header %s is missing", header)
+			}
+			value = strings.Join(values, ", ")
+		}
+		lines[i] = header + ": " + value
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Parses PEM encoded RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8) private key
+func ParsePrivateKeyPem(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, InvalidPemError
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, UnsupportedKeyError
+	}
+}
+
+// Reads and parses PEM encoded private key from a file, see ParsePrivateKeyPem
+func LoadPrivateKeyPem(path string) (crypto.Signer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKeyPem(data)
+}
+
+func readBody(request *corehttp.Request) ([]byte, error) {
+	if request.Body == nil || request.Body == corehttp.NoBody {
+		return []byte{}, nil
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
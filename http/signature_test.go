@@ -0,0 +1,177 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewHttpSignatureSignerWithInValidConfig(t *testing.T) {
+	ecdsaKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	testCases := []struct {
+		Config        HttpSignatureConfig
+		ExpectedError error
+	}{
+		{Config: HttpSignatureConfig{PrivateKey: ed25519Key}, ExpectedError: KeyIdEmptyError},
+		{Config: HttpSignatureConfig{KeyId: "key"}, ExpectedError: PrivateKeyEmptyError},
+		{Config: HttpSignatureConfig{KeyId: "key", PrivateKey: ecdsaKey}, ExpectedError: UnsupportedKeyError},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given invalid HttpSignatureConfig keyId=%s", testCase.Config.KeyId)
+
+		t.Logf("When creating HttpSignatureSigner")
+		signer, err := NewHttpSignatureSigner(testCase.Config)
+
+		t.Logf("Should return '%s' error", testCase.ExpectedError)
+		assert.EqualError(t, err, testCase.ExpectedError.Error())
+		assert.Nil(t, signer)
+	}
+}
+
+func TestParsePrivateKeyPem(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	testCases := []struct {
+		Name string
+		Pem  []byte
+		Key  crypto.Signer
+	}{
+		{Name: "RSA PKCS#1", Pem: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), Key: rsaKey},
+		{Name: "RSA PKCS#8", Pem: pkcs8Pem(rsaKey), Key: rsaKey},
+		{Name: "Ed25519 PKCS#8", Pem: pkcs8Pem(ed25519Key), Key: ed25519Key},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given %s private key PEM", testCase.Name)
+
+		t.Logf("When parsing it")
+		key, err := ParsePrivateKeyPem(testCase.Pem)
+
+		t.Logf("Should return the same key")
+		assert.NoError(t, err)
+		comparable, ok := key.(interface{ Equal(crypto.PrivateKey) bool })
+		assert.True(t, ok && comparable.Equal(testCase.Key))
+	}
+
+	t.Logf("Given not a PEM")
+	t.Logf("When parsing it")
+	key, err := ParsePrivateKeyPem([]byte("not a pem"))
+
+	t.Logf("Should return InvalidPemError")
+	assert.Equal(t, InvalidPemError, err)
+	assert.Nil(t, key)
+}
+
+func TestClient_PostWithHttpSignature(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	testCases := []struct {
+		Name       string
+		PrivateKey crypto.Signer
+	}{
+		{Name: "RSA", PrivateKey: rsaKey},
+		{Name: "Ed25519", PrivateKey: ed25519Key},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given HTTP server verifying %s signatures and failing the first call", testCase.Name)
+		var verificationErrors []error
+		var callCount int
+		server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			callCount++
+			verificationErrors = append(verificationErrors, verifySignature(req, testCase.PrivateKey.Public()))
+			if callCount == 1 {
+				res.WriteHeader(503)
+				return
+			}
+			res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+		}))
+		serverUrl, _ := url.Parse(server.URL + "/v1/organisation/accounts?page=1")
+
+		t.Logf("And given Client retrying all methods with HttpSignatureSigner")
+		signer, _ := NewHttpSignatureSigner(HttpSignatureConfig{KeyId: "75a8ba12-fff2-4a52-ad8a-e8b34c5ccec8", PrivateKey: testCase.PrivateKey})
+		config := validClientConfig
+		config.RetryPolicy = RetryAllMethodsPolicy
+		config.Signer = signer
+		client, _ := NewClient(config)
+
+		t.Logf("When calling POST")
+		var dummyResponse DummyResponse
+		err := client.Post(context.Background(), serverUrl, &DummyRequest{Title: "Jan"}, &dummyResponse)
+
+		t.Logf("Should sign both the first attempt and the retry")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, callCount)
+		assert.Equal(t, []error{nil, nil}, verificationErrors)
+		server.Close()
+	}
+}
+
+// Verifies `Signature` and `Digest` headers like the server side would do
+func verifySignature(request *http.Request, publicKey crypto.PublicKey) error {
+	body, _ := ioutil.ReadAll(request.Body)
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	digest := sha256.Sum256(body)
+	if request.Header.Get("Digest") != "SHA-256="+base64.StdEncoding.EncodeToString(digest[:]) {
+		return errors.New("digest mismatch")
+	}
+
+	parameters := make(map[string]string)
+	for _, parameter := range strings.Split(request.Header.Get("Signature"), ",") {
+		keyValue := strings.SplitN(parameter, "=", 2)
+		if len(keyValue) != 2 {
+			return fmt.Errorf("malformed signature parameter %s", parameter)
+		}
+		parameters[keyValue[0]] = strings.Trim(keyValue[1], `"`)
+	}
+	if parameters["keyId"] != "75a8ba12-fff2-4a52-ad8a-e8b34c5ccec8" {
+		return errors.New("unknown keyId")
+	}
+
+	signingString, err := SigningString(request, strings.Split(parameters["headers"], " "))
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(parameters["signature"])
+	if err != nil {
+		return err
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		hashed := sha256.Sum256([]byte(signingString))
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, []byte(signingString), signature) {
+			return errors.New("invalid ed25519 signature")
+		}
+		return nil
+	default:
+		return errors.New("unsupported key")
+	}
+}
+
+func pkcs8Pem(key interface{}) []byte {
+	bytes, _ := x509.MarshalPKCS8PrivateKey(key)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: bytes})
+}
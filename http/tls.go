@@ -0,0 +1,138 @@
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLS settings of the Client, e.g. for mTLS gateways.
+//
+// The client certificate is read either from CertFile and KeyFile or from CertPem and KeyPem, files take precedence.
+// Files are checked on every TLS handshake and the certificate is reloaded once any of them changes,
+// so rotated certificates are picked up by new connections without recreating the Client.
+//
+// Root CAs are read from CaFile or CaPem, system roots are used if none of them is provided.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	CertPem  []byte
+	KeyPem   []byte
+	CaFile   string
+	CaPem    []byte
+	// Overrides the server name used for verification and SNI
+	ServerName string
+	// Defaults to tls.VersionTLS12
+	MinVersion uint16
+}
+
+// Creates tls.Config from the TLSConfig.
+//
+// If only one of the certificate and key is provided it returns CertificateKeyMissingError,
+// if CA PEM contains no certificates it returns InvalidCaError.
+func NewTLSConfig(config TLSConfig) (*tls.Config, error) {
+	minVersion := config.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+	tlsConfig := &tls.Config{
+		ServerName: config.ServerName,
+		MinVersion: minVersion,
+	}
+
+	caPem := config.CaPem
+	if len(config.CaFile) > 0 {
+		data, err := ioutil.ReadFile(config.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		caPem = data
+	}
+	if len(caPem) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPem) {
+			return nil, InvalidCaError
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if len(config.CertFile) > 0 || len(config.KeyFile) > 0 {
+		if len(config.CertFile) == 0 || len(config.KeyFile) == 0 {
+			return nil, CertificateKeyMissingError
+		}
+		loader := &certificateLoader{certFile: config.CertFile, keyFile: config.KeyFile}
+		if _, err := loader.load(); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return loader.load()
+		}
+	} else if len(config.CertPem) > 0 || len(config.KeyPem) > 0 {
+		if len(config.CertPem) == 0 || len(config.KeyPem) == 0 {
+			return nil, CertificateKeyMissingError
+		}
+		certificate, err := tls.X509KeyPair(config.CertPem, config.KeyPem)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// Keeps the client certificate loaded from files and reloads it when modification time or size of the files change
+type certificateLoader struct {
+	certFile    string
+	keyFile     string
+	mutex       sync.Mutex
+	certificate *tls.Certificate
+	versions    [2]fileVersion
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func (c *certificateLoader) load() (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	versions, err := c.stat()
+	if err != nil {
+		if c.certificate != nil {
+			return c.certificate, nil
+		}
+		return nil, err
+	}
+	if c.certificate != nil && versions == c.versions {
+		return c.certificate, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		// files may be in the middle of being rotated, the previous certificate is kept until both are consistent
+		if c.certificate != nil {
+			return c.certificate, nil
+		}
+		return nil, err
+	}
+	c.certificate = &certificate
+	c.versions = versions
+	return c.certificate, nil
+}
+
+func (c *certificateLoader) stat() ([2]fileVersion, error) {
+	var versions [2]fileVersion
+	for i, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return versions, err
+		}
+		versions[i] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	}
+	return versions, nil
+}
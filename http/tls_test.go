@@ -0,0 +1,167 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewTLSConfigWithInvalidConfig(t *testing.T) {
+	ca := newTestCertificate(t, "ca", nil)
+	client := newTestCertificate(t, "client", ca)
+
+	testCases := []struct {
+		Config        TLSConfig
+		ExpectedError error
+	}{
+		{Config: TLSConfig{CertPem: client.certPem}, ExpectedError: CertificateKeyMissingError},
+		{Config: TLSConfig{KeyFile: "client.key"}, ExpectedError: CertificateKeyMissingError},
+		{Config: TLSConfig{CaPem: []byte("not a pem")}, ExpectedError: InvalidCaError},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given TLSConfig %+v", testCase.Config)
+
+		t.Logf("When creating tls.Config")
+		tlsConfig, err := NewTLSConfig(testCase.Config)
+
+		t.Logf("Should return '%s' error", testCase.ExpectedError)
+		assert.Equal(t, testCase.ExpectedError, err)
+		assert.Nil(t, tlsConfig)
+	}
+}
+
+func TestNewClientWithTLSAndCustomTransport(t *testing.T) {
+	t.Logf("Given ClientConfig with TLS and custom Transport")
+	config := validClientConfig
+	config.TLS = &TLSConfig{}
+	config.Transport = http.DefaultTransport
+
+	t.Logf("When creating Client")
+	client, err := NewClient(config)
+
+	t.Logf("Should return TransportConflictError")
+	assert.Equal(t, TransportConflictError, err)
+	assert.Nil(t, client)
+}
+
+func TestClient_GetWithMutualTLS(t *testing.T) {
+	t.Logf("Given CA with server and client certificates")
+	ca := newTestCertificate(t, "ca", nil)
+	serverCertificate := newTestCertificate(t, "accountapi.internal", ca)
+	clientCertificate := newTestCertificate(t, "first-client", ca)
+
+	t.Logf("And given HTTP server requiring client certificates")
+	var commonNames []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		commonNames = append(commonNames, req.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.certPem)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCertificate.tlsCertificate(t)},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	server.StartTLS()
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given certificate files")
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"), filepath.Join(dir, "ca.crt")
+	ioutil.WriteFile(certFile, clientCertificate.certPem, 0600)
+	ioutil.WriteFile(keyFile, clientCertificate.keyPem, 0600)
+	ioutil.WriteFile(caFile, ca.certPem, 0600)
+
+	t.Logf("And given Client with mTLS and server name override")
+	config := validClientConfig
+	config.TLS = &TLSConfig{CertFile: certFile, KeyFile: keyFile, CaFile: caFile, ServerName: "accountapi.internal"}
+	config.TransportConfig = &TransportConfig{DisableHTTP2: true}
+	client, err := NewClient(config)
+	assert.NoError(t, err)
+
+	t.Logf("When calling GET")
+	err = client.Get(context.Background(), serverUrl, nil)
+
+	t.Logf("Should authenticate with the client certificate")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first-client"}, commonNames)
+
+	t.Logf("When the certificate files are rotated and a new connection is made")
+	rotated := newTestCertificate(t, "second-client", ca)
+	ioutil.WriteFile(certFile, rotated.certPem, 0600)
+	ioutil.WriteFile(keyFile, rotated.keyPem, 0600)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	client.client.CloseIdleConnections()
+	err = client.Get(context.Background(), serverUrl, nil)
+
+	t.Logf("Should use the rotated certificate")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first-client", "second-client"}, commonNames)
+}
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPem     []byte
+	keyPem      []byte
+}
+
+// Creates a certificate signed by the parent, or a self-signed CA if the parent is nil
+func newTestCertificate(t *testing.T, commonName string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+	certificate, _ := x509.ParseCertificate(der)
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPem:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPem:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+}
+
+func (c *testCertificate) tlsCertificate(t *testing.T) tls.Certificate {
+	certificate, err := tls.X509KeyPair(c.certPem, c.keyPem)
+	assert.NoError(t, err)
+	return certificate
+}
@@ -0,0 +1,266 @@
+package http
+
+import (
+	"accountapi-client/logging"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	corehttp "net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identifies a span as defined by W3C Trace Context (https://www.w3.org/TR/trace-context/)
+type SpanContext struct {
+	TraceId    [16]byte
+	SpanId     [8]byte
+	Sampled    bool
+	TraceState string
+}
+
+// Returns true if both trace and span ids are set
+func (s SpanContext) IsValid() bool {
+	return s.TraceId != [16]byte{} && s.SpanId != [8]byte{}
+}
+
+// Formats the span context as `traceparent` header value, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func (s SpanContext) TraceParent() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", hex.EncodeToString(s.TraceId[:]), hex.EncodeToString(s.SpanId[:]), flags)
+}
+
+// Parses `traceparent` header value, it returns InvalidTraceParentError if it's malformed
+func ParseTraceParent(traceParent string) (SpanContext, error) {
+	var spanContext SpanContext
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return spanContext, InvalidTraceParentError
+	}
+	traceId, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceId) != 16 {
+		return spanContext, InvalidTraceParentError
+	}
+	spanId, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanId) != 8 {
+		return spanContext, InvalidTraceParentError
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return spanContext, InvalidTraceParentError
+	}
+
+	copy(spanContext.TraceId[:], traceId)
+	copy(spanContext.SpanId[:], spanId)
+	spanContext.Sampled = flags[0]&1 == 1
+	if !spanContext.IsValid() {
+		return SpanContext{}, InvalidTraceParentError
+	}
+	return spanContext, nil
+}
+
+// Reads `traceparent` and `tracestate` headers, e.g. of an incoming request, so they can be put into the context
+// with ContextWithSpanContext and propagated to outgoing calls
+func ExtractSpanContext(headers corehttp.Header) (SpanContext, bool) {
+	spanContext, err := ParseTraceParent(headers.Get("traceparent"))
+	if err != nil {
+		return SpanContext{}, false
+	}
+	spanContext.TraceState = strings.Join(headers.Values("tracestate"), ",")
+	return spanContext, true
+}
+
+// Sets `traceparent` and `tracestate` headers, nothing is set if the span context is not valid
+func InjectSpanContext(spanContext SpanContext, headers corehttp.Header) {
+	if !spanContext.IsValid() {
+		return
+	}
+	headers.Set("traceparent", spanContext.TraceParent())
+	if len(spanContext.TraceState) > 0 {
+		headers.Set("tracestate", spanContext.TraceState)
+	} else {
+		headers.Del("tracestate")
+	}
+}
+
+type spanContextKey struct{}
+
+// Returns a copy of the context carrying the span context, spans started by a Tracer with it become its children
+func ContextWithSpanContext(ctx context.Context, spanContext SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, spanContext)
+}
+
+// Returns the span context stored with ContextWithSpanContext
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	spanContext, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return spanContext, ok
+}
+
+// Starts spans, implementations are expected to make the new span a child of the span context found in ctx
+// and return ctx carrying the new span context (see ContextWithSpanContext).
+//
+// The Client starts one span per call and a child span per attempt, the span context of the attempt is propagated
+// with `traceparent` and `tracestate` headers.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+type noopTracer struct{}
+
+type noopSpan struct {
+	spanContext SpanContext
+}
+
+// Returns a Tracer which doesn't record anything, spans share the span context of their parent
+// so headers from the caller's context are still propagated.
+func NoopTracer() Tracer {
+	return noopTracer{}
+}
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	spanContext, _ := SpanContextFromContext(ctx)
+	return ctx, noopSpan{spanContext: spanContext}
+}
+
+func (s noopSpan) SpanContext() SpanContext                   { return s.spanContext }
+func (s noopSpan) SetAttribute(key string, value interface{}) {}
+func (s noopSpan) RecordError(err error)                      {}
+func (s noopSpan) End()                                       {}
+
+// Tracer keeping all the spans in memory, meant to be used in tests
+type RecordingTracer struct {
+	mutex sync.Mutex
+	spans []*RecordedSpan
+}
+
+// Span recorded by RecordingTracer, Parent is empty for root spans
+type RecordedSpan struct {
+	Name        string
+	Context     SpanContext
+	Parent      SpanContext
+	Attributes  map[string]interface{}
+	Errors      []error
+	StartTime   time.Time
+	EndTime     time.Time
+	tracerMutex *sync.Mutex
+}
+
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+func (r *RecordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := SpanContextFromContext(ctx)
+	spanContext := SpanContext{TraceId: parent.TraceId, Sampled: true, TraceState: parent.TraceState}
+	if !parent.IsValid() {
+		rand.Read(spanContext.TraceId[:])
+	}
+	rand.Read(spanContext.SpanId[:])
+
+	span := &RecordedSpan{
+		Name:        name,
+		Context:     spanContext,
+		Parent:      parent,
+		Attributes:  make(map[string]interface{}),
+		StartTime:   time.Now(),
+		tracerMutex: &r.mutex,
+	}
+	r.mutex.Lock()
+	r.spans = append(r.spans, span)
+	r.mutex.Unlock()
+	return ContextWithSpanContext(ctx, spanContext), span
+}
+
+// Returns copies of all the spans in the order they were started
+func (r *RecordingTracer) Spans() []RecordedSpan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	spans := make([]RecordedSpan, len(r.spans))
+	for i, span := range r.spans {
+		spans[i] = *span
+		spans[i].tracerMutex = nil
+		spans[i].Attributes = make(map[string]interface{}, len(span.Attributes))
+		for key, value := range span.Attributes {
+			spans[i].Attributes[key] = value
+		}
+	}
+	return spans
+}
+
+func (s *RecordedSpan) SpanContext() SpanContext {
+	return s.Context
+}
+
+func (s *RecordedSpan) SetAttribute(key string, value interface{}) {
+	s.tracerMutex.Lock()
+	defer s.tracerMutex.Unlock()
+	s.Attributes[key] = value
+}
+
+func (s *RecordedSpan) RecordError(err error) {
+	s.tracerMutex.Lock()
+	defer s.tracerMutex.Unlock()
+	s.Errors = append(s.Errors, err)
+}
+
+func (s *RecordedSpan) End() {
+	s.tracerMutex.Lock()
+	defer s.tracerMutex.Unlock()
+	s.EndTime = time.Now()
+}
+
+// Starts a span for the whole call (named after the operation, see WithOperation)
+func callTracingMiddleware(tracer Tracer) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			ctx, span := tracer.Start(request.Context(), OperationFromRequest(request))
+			defer span.End()
+			span.SetAttribute("http.method", request.Method)
+			span.SetAttribute("http.url", logging.RedactUrl(request.URL))
+
+			response, err := next.RoundTrip(request.WithContext(ctx))
+			recordOutcome(span, response, err)
+			return response, err
+		})
+	}
+}
+
+// Starts a child span for every attempt and propagates it with `traceparent` and `tracestate` headers
+func attemptTracingMiddleware(tracer Tracer) Middleware {
+	return func(next corehttp.RoundTripper) corehttp.RoundTripper {
+		return RoundTripperFunc(func(request *corehttp.Request) (*corehttp.Response, error) {
+			ctx, span := tracer.Start(request.Context(), OperationFromRequest(request)+" attempt")
+			defer span.End()
+			span.SetAttribute("http.attempt", AttemptFromContext(request.Context()))
+
+			traced := request.Clone(ctx)
+			InjectSpanContext(span.SpanContext(), traced.Header)
+			response, err := next.RoundTrip(traced)
+			recordOutcome(span, response, err)
+			return response, err
+		})
+	}
+}
+
+func recordOutcome(span Span, response *corehttp.Response, err error) {
+	if err != nil {
+		span.SetAttribute("error", true)
+		span.RecordError(err)
+		return
+	}
+	span.SetAttribute("http.status_code", response.StatusCode)
+	if response.StatusCode >= 400 {
+		span.SetAttribute("error", true)
+	}
+}
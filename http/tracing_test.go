@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	testCases := []struct {
+		TraceParent   string
+		ExpectedError error
+	}{
+		{TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{TraceParent: "", ExpectedError: InvalidTraceParentError},
+		{TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", ExpectedError: InvalidTraceParentError},
+		{TraceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ExpectedError: InvalidTraceParentError},
+		{TraceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", ExpectedError: InvalidTraceParentError},
+		{TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", ExpectedError: InvalidTraceParentError},
+		{TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", ExpectedError: InvalidTraceParentError},
+	}
+
+	for _, testCase := range testCases {
+		t.Logf("Given traceparent %s", testCase.TraceParent)
+
+		t.Logf("When parsing it")
+		spanContext, err := ParseTraceParent(testCase.TraceParent)
+
+		if testCase.ExpectedError != nil {
+			t.Logf("Should return '%s' error", testCase.ExpectedError)
+			assert.Equal(t, testCase.ExpectedError, err)
+		} else {
+			t.Logf("Should format back to the same value")
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.TraceParent, spanContext.TraceParent())
+		}
+	}
+}
+
+func TestClient_GetWithRecordingTracer(t *testing.T) {
+	t.Logf("Given HTTP server failing the first call")
+	var traceParents, traceStates []string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		traceParents = append(traceParents, req.Header.Get("traceparent"))
+		traceStates = append(traceStates, req.Header.Get("tracestate"))
+		if len(traceParents) == 1 {
+			res.WriteHeader(503)
+			return
+		}
+		res.Write([]byte(`{"id": 1, "title": "Jan"}`))
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with RecordingTracer")
+	tracer := NewRecordingTracer()
+	config := validClientConfig
+	config.Tracer = tracer
+	client, _ := NewClient(config)
+
+	t.Logf("And given caller's context with a span context")
+	headers := http.Header{}
+	headers.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	headers.Set("tracestate", "congo=t61rcWkgMzE")
+	parent, _ := ExtractSpanContext(headers)
+	ctx := ContextWithSpanContext(WithOperation(context.Background(), "dummy.get"), parent)
+
+	t.Logf("When calling GET")
+	var dummyResponse DummyResponse
+	err := client.Get(ctx, serverUrl, &dummyResponse)
+
+	t.Logf("Should record a call span with a child span per attempt")
+	assert.NoError(t, err)
+	spans := tracer.Spans()
+	assert.Equal(t, 3, len(spans))
+	call, firstAttempt, secondAttempt := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "dummy.get", call.Name)
+	assert.Equal(t, parent, call.Parent)
+	assert.Equal(t, parent.TraceId, call.Context.TraceId)
+	assert.Equal(t, 200, call.Attributes["http.status_code"])
+	assert.False(t, call.EndTime.IsZero())
+
+	assert.Equal(t, "dummy.get attempt", firstAttempt.Name)
+	assert.Equal(t, call.Context, firstAttempt.Parent)
+	assert.Equal(t, 1, firstAttempt.Attributes["http.attempt"])
+	assert.Equal(t, 503, firstAttempt.Attributes["http.status_code"])
+	assert.Equal(t, true, firstAttempt.Attributes["error"])
+
+	assert.Equal(t, call.Context, secondAttempt.Parent)
+	assert.Equal(t, 2, secondAttempt.Attributes["http.attempt"])
+	assert.Equal(t, 200, secondAttempt.Attributes["http.status_code"])
+
+	t.Logf("And should propagate attempt spans with traceparent and tracestate headers")
+	assert.Equal(t, []string{firstAttempt.Context.TraceParent(), secondAttempt.Context.TraceParent()}, traceParents)
+	assert.Equal(t, []string{"congo=t61rcWkgMzE", "congo=t61rcWkgMzE"}, traceStates)
+}
+
+func TestClient_GetWithNoopTracer(t *testing.T) {
+	t.Logf("Given HTTP server")
+	var traceParent string
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		traceParent = req.Header.Get("traceparent")
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client without Tracer")
+	client, _ := NewClient(validClientConfig)
+
+	t.Logf("When calling GET with a span context")
+	parent, _ := ParseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	err := client.Get(ContextWithSpanContext(context.Background(), parent), serverUrl, nil)
+
+	t.Logf("Should propagate caller's traceparent")
+	assert.NoError(t, err)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceParent)
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"crypto/tls"
+	"net"
+	corehttp "net/http"
+	"time"
+)
+
+// Tuning of the transport created by NewTransport, zero values fall back to net/http.DefaultTransport settings
+type TransportConfig struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	// Zero means no limit
+	MaxConnsPerHost int
+	IdleConnTimeout time.Duration
+	// Limits establishing of TCP connections
+	DialTimeout time.Duration
+	KeepAlive   time.Duration
+	// Limits TLS handshakes of new connections
+	TLSHandshakeTimeout time.Duration
+	// Limits waiting for response headers once the request is written, zero means no limit
+	ResponseHeaderTimeout time.Duration
+	// HTTP/2 is negotiated over TLS unless it's disabled
+	DisableHTTP2 bool
+	// See NewTLSConfig
+	TLSClientConfig *tls.Config
+}
+
+// Creates a transport pooling connections according to the TransportConfig
+func NewTransport(config TransportConfig) *corehttp.Transport {
+	transport := corehttp.DefaultTransport.(*corehttp.Transport).Clone()
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if config.DialTimeout > 0 {
+		dialer.Timeout = config.DialTimeout
+	}
+	if config.KeepAlive != 0 {
+		dialer.KeepAlive = config.KeepAlive
+	}
+	transport.DialContext = dialer.DialContext
+
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
+	}
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+	if config.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = config.MaxConnsPerHost
+	}
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+	if config.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	}
+	if config.TLSClientConfig != nil {
+		transport.TLSClientConfig = config.TLSClientConfig
+	}
+	if config.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) corehttp.RoundTripper)
+	}
+	return transport
+}
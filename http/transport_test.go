@@ -0,0 +1,103 @@
+package http
+
+import (
+	"accountapi-client/retry"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	t.Logf("Given TransportConfig")
+	config := TransportConfig{
+		MaxIdleConnsPerHost:   20,
+		IdleConnTimeout:       time.Minute,
+		TLSHandshakeTimeout:   time.Second,
+		ResponseHeaderTimeout: 2 * time.Second,
+		DisableHTTP2:          true,
+	}
+
+	t.Logf("When creating transport")
+	transport := NewTransport(config)
+
+	t.Logf("Should apply provided settings and keep defaults of the others")
+	assert.Equal(t, 20, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	assert.Equal(t, time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 2*time.Second, transport.ResponseHeaderTimeout)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.TLSNextProto)
+}
+
+func TestClient_GetWithResponseHeaderTimeout(t *testing.T) {
+	t.Logf("Given slow HTTP server")
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with response header timeout shorter than the overall timeout")
+	config := validClientConfig
+	config.TransportConfig = &TransportConfig{ResponseHeaderTimeout: 10 * time.Millisecond}
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	err := client.Get(context.Background(), serverUrl, nil)
+
+	t.Logf("Should return retryable network error")
+	var clientError *ClientError
+	assert.True(t, errors.As(err, &clientError))
+	assert.Equal(t, NetworkErrorClass, clientError.Class)
+}
+
+func TestClient_GetWithCustomTransport(t *testing.T) {
+	t.Logf("Given custom transport")
+	var calls int
+	transport := RoundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		calls++
+		return httptest.NewRecorder().Result(), nil
+	})
+
+	t.Logf("And given Client using it")
+	config := validClientConfig
+	config.Transport = transport
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	err := client.Get(context.Background(), createUrl("http://localhost:1"), nil)
+
+	t.Logf("Should send the request with it")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestClient_GetWithCallTimeout(t *testing.T) {
+	t.Logf("Given HTTP server always failing")
+	callCount := make(map[string]int)
+	server := httptest.NewServer(requestHandler(503, &callCount))
+	serverUrl, _ := url.Parse(server.URL)
+	defer server.Close()
+
+	t.Logf("And given Client with long retry delays and short call budget")
+	config := validClientConfig
+	config.Retries = &retry.RetriesConfig{MaxRetries: 3, Delay: 30 * time.Millisecond, Factor: 2}
+	config.CallTimeout = 50 * time.Millisecond
+	client, _ := NewClient(config)
+
+	t.Logf("When calling GET")
+	startTime := time.Now()
+	err := client.Get(context.Background(), serverUrl, nil)
+
+	t.Logf("Should stop retrying once the budget is exhausted")
+	assert.Error(t, err)
+	assert.True(t, callCount["/"] < 4)
+	elapsed := time.Now().Sub(startTime)
+	assert.True(t, elapsed < 90*time.Millisecond, elapsed.String())
+}
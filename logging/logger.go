@@ -0,0 +1,111 @@
+// Package that provides a minimal structured logging interface used by the http and account clients.
+//
+// Logger accepts a Level, a message and a list of key/value Field, the NewStdLogger function adapts it to
+// go's built in log.Logger, the Nop function returns a Logger which drops everything.
+//
+// Values logged by the clients are passed through Redact functions first, so IBANs, account numbers and names
+// do not end up in logs.
+//
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+type Level int
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Debug:
+		return "DEBUG"
+	case Info:
+		return "INFO"
+	case Warn:
+		return "WARN"
+	case Error:
+		return "ERROR"
+	default:
+		return fmt.Sprintf("LEVEL(%d)", int(l))
+	}
+}
+
+// Key/value pair attached to a log record
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Shorthand for creating a Field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+type Logger interface {
+	Log(level Level, message string, fields ...Field)
+}
+
+// Adapter that allows to use an ordinary function as a Logger
+type LoggerFunc func(level Level, message string, fields ...Field)
+
+func (f LoggerFunc) Log(level Level, message string, fields ...Field) {
+	f(level, message, fields...)
+}
+
+// Returns a Logger which drops all the records
+func Nop() Logger {
+	return LoggerFunc(func(level Level, message string, fields ...Field) {})
+}
+
+type stdLogger struct {
+	logger   *log.Logger
+	minLevel Level
+}
+
+// Creates a Logger writing records with minLevel or above to the log.Logger as a single line:
+//
+// 	INFO Outgoing request method=GET url=http://localhost:8080/v1/organisation/accounts attempt=1
+//
+// If logger is nil, records are written with the standard logger (log.Print).
+func NewStdLogger(logger *log.Logger, minLevel Level) Logger {
+	return &stdLogger{logger: logger, minLevel: minLevel}
+}
+
+func (l *stdLogger) Log(level Level, message string, fields ...Field) {
+	if level < l.minLevel {
+		return
+	}
+	line := Format(level, message, fields...)
+	if l.logger == nil {
+		log.Print(line)
+		return
+	}
+	l.logger.Print(line)
+}
+
+// Formats the record as `LEVEL message key=value key=value`, values containing spaces are quoted
+func Format(level Level, message string, fields ...Field) string {
+	var builder strings.Builder
+	builder.WriteString(level.String())
+	builder.WriteString(" ")
+	builder.WriteString(message)
+	for _, field := range fields {
+		value := fmt.Sprint(field.Value)
+		if strings.ContainsAny(value, " \t\n\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		builder.WriteString(" ")
+		builder.WriteString(field.Key)
+		builder.WriteString("=")
+		builder.WriteString(value)
+	}
+	return builder.String()
+}
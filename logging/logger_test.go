@@ -0,0 +1,35 @@
+package logging
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	t.Logf("Given a record with fields")
+	fields := []Field{F("method", "GET"), F("status", 200), F("error", "connection refused")}
+
+	t.Logf("When formatting it")
+	line := Format(Warn, "Outgoing request failed", fields...)
+
+	t.Logf("Should write level, message and quote values with spaces")
+	assert.Equal(t, `WARN Outgoing request failed method=GET status=200 error="connection refused"`, line)
+}
+
+func TestStdLogger(t *testing.T) {
+	t.Logf("Given std logger with Info level")
+	var buffer bytes.Buffer
+	logger := NewStdLogger(log.New(&buffer, "", 0), Info)
+
+	t.Logf("When logging on all the levels")
+	logger.Log(Debug, "debug")
+	logger.Log(Info, "info", F("attempt", 1))
+	logger.Log(Warn, "warn")
+	logger.Log(Error, "error")
+
+	t.Logf("Should skip records below Info")
+	assert.Equal(t, []string{"INFO info attempt=1", "WARN warn", "ERROR error"}, strings.Split(strings.TrimSpace(buffer.String()), "\n"))
+}
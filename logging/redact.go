@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Replacement of redacted values
+const Redacted = "[REDACTED]"
+
+// Keys of JSON attributes and query parameters which values are always redacted
+var SensitiveKeys = []string{"iban", "account_number", "name", "alternative_names", "secondary_identification"}
+
+var ibanPattern = regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}\b`)
+
+// Replaces everything that looks like an IBAN in the string
+func Redact(value string) string {
+	return ibanPattern.ReplaceAllString(value, Redacted)
+}
+
+// Redacts the password of userinfo, query parameters with SensitiveKeys and IBANs anywhere in the URL
+func RedactUrl(value *url.URL) string {
+	if value == nil {
+		return ""
+	}
+	redacted := *value
+	if redacted.User != nil {
+		redacted.User = url.User(redacted.User.Username())
+	}
+	query := redacted.Query()
+	if len(query) > 0 {
+		for key := range query {
+			if isSensitive(key) {
+				query[key] = []string{Redacted}
+			}
+		}
+		redacted.RawQuery = query.Encode()
+	}
+	return Redact(redacted.String())
+}
+
+// Redacts values of JSON attributes with SensitiveKeys (at any depth) and IBANs anywhere in the body.
+// Bodies which are not valid JSON are only redacted with Redact.
+func RedactBody(body []byte) string {
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return Redact(string(body))
+	}
+	redacted, err := json.Marshal(redactValue(parsed))
+	if err != nil {
+		return Redact(string(body))
+	}
+	return Redact(string(redacted))
+}
+
+func redactValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range value {
+			if isSensitive(key) && nested != nil {
+				value[key] = Redacted
+			} else {
+				value[key] = redactValue(nested)
+			}
+		}
+		return value
+	case []interface{}:
+		for i, nested := range value {
+			value[i] = redactValue(nested)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range SensitiveKeys {
+		if key == sensitive {
+			return true
+		}
+	}
+	return false
+}
@@ -4,11 +4,15 @@ import "errors"
 
 // Errors returned during creation of the Retry by NewRetries
 var (
-	MaxRetriesZeroError = errors.New("maxRetries has to be larger than 0")
-	DelayZeroError      = errors.New("delay has to be larger than 0")
-	FactorZeroError     = errors.New("factor has to be larger than 0")
+	ConfigNilError          = errors.New("retries config cannot be nil")
+	MaxRetriesNegativeError = errors.New("maxRetries cannot be negative")
+	DelayZeroError          = errors.New("delay has to be larger than 0")
+	FactorZeroError         = errors.New("factor has to be larger than 0")
 )
 
+// Deprecated: MaxRetries of zero disables retries, use MaxRetriesNegativeError
+var MaxRetriesZeroError = MaxRetriesNegativeError
+
 // Returned by the caller within Retry.Execute whenever there's a need to do a retry.
 // Returning an error of a different type means there should be no retry
 type RetryableError struct {
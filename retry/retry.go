@@ -15,24 +15,38 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"math"
 	"time"
 )
 
 type RetriesConfig struct {
+	// Zero disables retries, Delay and Factor are not required then
 	MaxRetries int
 	Delay      time.Duration
 	Factor     float64
 }
 
+// Returns a new copy of the default config: 3 retries, 100ms delay and factor 2
+func DefaultRetriesConfig() *RetriesConfig {
+	return &RetriesConfig{MaxRetries: 3, Delay: 100 * time.Millisecond, Factor: 2}
+}
+
 // Constructs new Retry from RetriesConfig
-// If RetriesConfig.MaxRetries is zero or below, it returns MaxRetriesZeroError
+// If RetriesConfig is nil, it returns ConfigNilError
+// If RetriesConfig.MaxRetries is below zero, it returns MaxRetriesNegativeError
 // If RetriesConfig.Delay is zero or below, it returns DelayZeroError
 // If RetriesConfig.Factor is zero or below, it returns FactorZeroError
 func NewRetries(config *RetriesConfig) (*Retry, error) {
-	if config.MaxRetries <= 0 {
-		return nil, MaxRetriesZeroError
+	if config == nil {
+		return nil, ConfigNilError
+	}
+	if config.MaxRetries < 0 {
+		return nil, MaxRetriesNegativeError
+	}
+	if config.MaxRetries == 0 {
+		return &Retry{config: config}, nil
 	}
 	if config.Delay.Milliseconds() <= 0 {
 		return nil, DelayZeroError
@@ -59,6 +73,12 @@ type RetryFunc func() error
 // The delay between retries is calculated based on a simple exponential-backoff equation: delay * factor^currentTry
 // Providing delay of 1 second, factor 2.0  and maximum number of retires will retry in 1s, 3s and 7s of delay between runs
 func (r *Retry) Execute(runnable RetryFunc) error {
+	return r.ExecuteContext(context.Background(), runnable)
+}
+
+// Runs provided RetryFunc like Execute, but stops waiting for the next run once the context is done
+// and returns the context's error then.
+func (r *Retry) ExecuteContext(ctx context.Context, runnable RetryFunc) error {
 	var tryCount int
 	for {
 		err := runnable()
@@ -82,8 +102,12 @@ func (r *Retry) Execute(runnable RetryFunc) error {
 		}
 
 		tryCount++
+		timer := time.NewTimer(r.next(tryCount))
 		select {
-		case <-time.After(r.next(tryCount)):
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
 		}
 
 	}
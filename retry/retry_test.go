@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"context"
 	"errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -31,8 +32,7 @@ func TestNewRetriesWithInValidConfig(t *testing.T) {
 		Factor        float64
 		ExpectedError error
 	}{
-		{MaxRetries: 0, Delay: time.Second, Factor: 1.0, ExpectedError: MaxRetriesZeroError},
-		{MaxRetries: -1, Delay: time.Second, Factor: 1.0, ExpectedError: MaxRetriesZeroError},
+		{MaxRetries: -1, Delay: time.Second, Factor: 1.0, ExpectedError: MaxRetriesNegativeError},
 		{MaxRetries: 1, Delay: 0 * time.Second, Factor: 1.0, ExpectedError: DelayZeroError},
 		{MaxRetries: 1, Delay: -1 * time.Second, Factor: 1.0, ExpectedError: DelayZeroError},
 		{MaxRetries: 1, Delay: time.Second, Factor: 0, ExpectedError: FactorZeroError},
@@ -55,6 +55,41 @@ func TestNewRetriesWithInValidConfig(t *testing.T) {
 	}
 }
 
+func TestNewRetriesWithNilConfig(t *testing.T) {
+	t.Logf("Given nil RetriesConfig")
+
+	t.Logf("When creating Retry")
+	retry, err := NewRetries(nil)
+
+	t.Logf("Should return '%s' error", ConfigNilError)
+	assert.Equal(t, ConfigNilError, err)
+	assert.Nil(t, retry)
+}
+
+func TestRetryWithDisabledRetries(t *testing.T) {
+	t.Logf("Given RetriesConfig with zero maxRetries and no delay")
+	config := RetriesConfig{MaxRetries: 0}
+
+	t.Logf("And given Retry")
+	retry, err := NewRetries(&config)
+	assert.NoError(t, err)
+
+	t.Logf("And given a func always failing with retryable error")
+	var callCount int
+	expectedErr := errors.New("unavailable")
+	funcToRetry := func() error {
+		callCount++
+		return &RetryableError{Err: expectedErr}
+	}
+
+	t.Logf("When executing a func")
+	err = retry.Execute(funcToRetry)
+
+	t.Logf("Should call only once and return the error")
+	assert.Equal(t, 1, callCount)
+	assert.Equal(t, expectedErr, err)
+}
+
 func TestRetryWithSuccessAtFirstTry(t *testing.T) {
 	maxRetries := 3
 	delay := time.Millisecond
@@ -149,6 +184,28 @@ func TestRetryWithConstantFailures(t *testing.T) {
 	assert.EqualError(t, err, expectedError.Error())
 }
 
+func TestRetryWithContextDoneDuringDelay(t *testing.T) {
+	t.Logf("Given Retry with a long delay")
+	retry, _ := NewRetries(&RetriesConfig{MaxRetries: 3, Delay: time.Second, Factor: 2})
+
+	t.Logf("And given context with a short timeout")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	t.Logf("When executing a constantly failing func")
+	var callCount int
+	startTime := time.Now()
+	err := retry.ExecuteContext(ctx, func() error {
+		callCount++
+		return &RetryableError{Err: errors.New("something is wrong")}
+	})
+
+	t.Logf("Should stop waiting once the context is done")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, callCount)
+	assert.True(t, time.Now().Sub(startTime) < 200*time.Millisecond)
+}
+
 func TestExponentialBackoff(t *testing.T) {
 	testCases := []struct {
 		MaxRetries    int